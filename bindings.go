@@ -0,0 +1,73 @@
+package grpcwot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// Binding maps an operation on an interaction affordance to the RPC which serves it,
+// so a gateway can translate the interactions of WoT consumers into gRPC calls
+type Binding struct {
+	// Operation type of the form, e.g. readproperty, writeproperty, invokeaction or subscribeevent
+	Op string
+
+	// Target IRI of the gRPC form relative to the base of the TD, without URI template
+	Href string
+
+	// Fully qualified name of the gRPC service, e.g. acme.lamp.v1.Lamp
+	Service string
+
+	// Name of the RPC
+	RPC string
+
+	// Fields of the request which are given as URI variables
+	UriVariables []string
+
+	// Interval in which the getter of a readproperty binding is polled to observe the property,
+	// zero if the default interval of the gateway is used
+	ObserveInterval time.Duration
+}
+
+// FullMethod returns the full method name of the RPC, e.g. /acme.lamp.v1.Lamp/GetBrightness
+func (b Binding) FullMethod() string {
+	return "/" + b.Service + "/" + b.RPC
+}
+
+// observeDuration parses the interval in which the getter of the property is polled for observation
+func observeDuration(get affs, interval string) (time.Duration, error) {
+	if interval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return 0, errors.New("The ObserveInterval " + interval + " of RPC " + get.Name + " is not a positive duration")
+	}
+	return d, nil
+}
+
+// bind records that the operation on the affordance named n is served by the RPC
+func (b *builder) bind(op, n, rpc string, uriVariables []string) {
+	service := b.td.Title
+	if b.pkg != "" {
+		service = b.pkg + "." + service
+	}
+	b.bindings = append(b.bindings, Binding{
+		Op:           op,
+		Href:         b.GetIRI(n),
+		Service:      service,
+		RPC:          rpc,
+		UriVariables: uriVariables,
+	})
+}
+
+// GenerateBindings generates the TD of the proto file without writing it and returns the bindings of its
+// operations to the RPCs. Without a classification config, the RPCs are classified without asking the user
+func GenerateBindings(protoFile, classConfigFile, ip string, port int, opts ...Option) (wot.ThingDescription, []Binding, error) {
+	b, err := generate(protoFile, classConfigFile, ip, port, true, opts...)
+	if err != nil {
+		return wot.ThingDescription{}, nil, err
+	}
+	return b.td, b.bindings, nil
+}
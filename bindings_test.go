@@ -0,0 +1,48 @@
+package grpcwot
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestGenerateBindings(t *testing.T) {
+	_, bindings, err := GenerateBindings("cmd/prototd/test/uri-variables/input.proto",
+		"cmd/prototd/test/uri-variables/config.json", "127.0.0.1", 50051)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].RPC < bindings[j].RPC })
+	expected := []Binding{
+		{Op: "readproperty", Href: "Mixer/ChannelLevel", Service: "Mixer", RPC: "GetChannelLevel", UriVariables: []string{"channel"}},
+		{Op: "readproperty", Href: "Mixer/Mute", Service: "Mixer", RPC: "GetMute"},
+		{Op: "writeproperty", Href: "Mixer/ChannelLevel", Service: "Mixer", RPC: "SetChannelLevel"},
+	}
+	if !reflect.DeepEqual(bindings, expected) {
+		t.Errorf("Expected %v, but got %v", expected, bindings)
+	}
+	if bindings[0].FullMethod() != "/Mixer/GetChannelLevel" {
+		t.Errorf("Expected the full method /Mixer/GetChannelLevel, but got %s", bindings[0].FullMethod())
+	}
+}
+
+func TestObserveDuration(t *testing.T) {
+	tests := []struct {
+		interval string
+		expected time.Duration
+		valid    bool
+	}{
+		{"", 0, true},
+		{"250ms", 250 * time.Millisecond, true},
+		{"1m", time.Minute, true},
+		{"-1s", 0, false},
+		{"often", 0, false},
+	}
+	for _, tt := range tests {
+		d, err := observeDuration(affs{Name: "GetLevel"}, tt.interval)
+		if (err == nil) != tt.valid || d != tt.expected {
+			t.Errorf("%q: expected %v (valid: %v), but got %v, %v", tt.interval, tt.expected, tt.valid, d, err)
+		}
+	}
+}
@@ -2,16 +2,20 @@ package grpcwot
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha1"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
 	"github.com/emicklei/proto"
-	"github.com/linksmart/thing-directory/wot"
 )
 
 type builder struct {
@@ -20,21 +24,91 @@ type builder struct {
 	iab         *interactionAffordanceBuilder
 	ip          string
 	port        int
+	pkg         string
+	security    securityConfig
 	ac          map[string]affClassConfig
+	bindings    []Binding
 	handleError error
+
+	// base URL of the grpc-gateway serving the HTTP bindings of the RPCs, no HTTP forms are generated if it is empty
+	httpGatewayURL string
 }
 
+// noSecurityScheme is the name of the default security definition, as gRPC services do not require security by default
+const noSecurityScheme = "nosec_sc"
+
 type affClassConfig struct {
-	AffClass string
-	Name     string `json:"Name,omitempty"`
+	AffClass     string
+	Name         string   `json:"Name,omitempty"`
+	UriVariables []string `json:"UriVariables,omitempty"`
+	Security     []string `json:"Security,omitempty"`
+	Errors       []string `json:"Errors,omitempty"`
+
+	// operation served by the RPC of a property, readproperty or writeproperty. It is written for consumers of the
+	// classification and not read by the generation
+	Op string `json:"Op,omitempty"`
+
+	// interval in which the gateway polls the getter of an observed property, e.g. 2s
+	ObserveInterval string `json:"ObserveInterval,omitempty"`
+}
+
+// affClassConfigs is the classification config, which is written with its entries in the order of the names
+type affClassConfigs struct {
+	names   []string
+	configs map[string]affClassConfig
+}
+
+// MarshalJSON writes the entries in the order of the names, entries without a name follow sorted by RPC
+func (c affClassConfigs) MarshalJSON() ([]byte, error) {
+	names := make([]string, 0, len(c.configs))
+	written := make(map[string]bool, len(c.configs))
+	for _, n := range c.names {
+		if _, ok := c.configs[n]; ok && !written[n] {
+			names = append(names, n)
+			written[n] = true
+		}
+	}
+	var rest []string
+	for n := range c.configs {
+		if !written[n] {
+			rest = append(rest, n)
+		}
+	}
+	sort.Strings(rest)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, n := range append(names, rest...) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(n)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(c.configs[n])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
 
 func newBuilder(ip string, port int, dsb *dataSchemaBuilder) *builder {
 	return &builder{
 		td: wot.ThingDescription{
+			Context:    wot.ContextV11,
+			Base:       fmt.Sprintf("http://%s:%d/", ip, port),
 			Properties: map[string]wot.PropertyAffordance{},
 			Actions:    map[string]wot.ActionAffordance{},
 			Events:     map[string]wot.EventAffordance{},
+			Security:   noSecurityScheme,
+			SecurityDefinitions: map[string]wot.SecurityScheme{
+				noSecurityScheme: {Scheme: "nosec"},
+			},
 		},
 		dsb:  dsb,
 		ip:   ip,
@@ -43,14 +117,57 @@ func newBuilder(ip string, port int, dsb *dataSchemaBuilder) *builder {
 	}
 }
 
-// GetIRI returns the target IRI for the RPC
+// GetIRI returns the target IRI for the RPC relative to the base of the TD
 func (b *builder) GetIRI(rpcName string) string {
-	return fmt.Sprintf("http://%s:%d/%s/%s", b.ip, b.port, b.td.Title, rpcName)
+	return fmt.Sprintf("%s/%s", b.td.Title, rpcName)
 }
 
 // HandleService assigns the Title for the resulting TD
+// and reads the security of the Thing from the (wot.thing) option
 func (b *builder) HandleService(s *proto.Service) {
 	b.td.Title = s.Name
+	if c, ok := thingOption(s); ok {
+		b.security = c
+	}
+}
+
+// HandlePackage assigns the version of the resulting TD, if the package name ends with a version, e.g. acme.device.v1
+func (b *builder) HandlePackage(p *proto.Package) {
+	b.pkg = p.Name
+	if v := packageVersion(p.Name); v != "" {
+		b.td.Version = &wot.VersionInfo{Instance: v}
+	}
+}
+
+// packageVersionPattern matches the version suffix of a package according to the Protocol Buffers Style Guide
+var packageVersionPattern = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+
+// packageVersion returns the version suffix of the package name or an empty string
+func packageVersion(pkg string) string {
+	parts := strings.Split(pkg, ".")
+	if v := parts[len(parts)-1]; packageVersionPattern.MatchString(v) {
+		return v
+	}
+	return ""
+}
+
+// namespaceURL is the namespace for name-based UUIDs of URLs defined in RFC 4122
+var namespaceURL = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// thingID returns an urn:uuid identifier which is derived from the fully qualified service name,
+// so the id is stable for all TDs generated from the same service (name-based UUID version 5 of RFC 4122)
+func (b *builder) thingID() string {
+	name := b.td.Title
+	if b.pkg != "" {
+		name = b.pkg + "." + name
+	}
+	h := sha1.New()
+	h.Write(namespaceURL[:])
+	h.Write([]byte("grpc:///" + name))
+	u := h.Sum(nil)[:16]
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
 }
 
 // getForms is a helper method to build the forms
@@ -58,7 +175,7 @@ func (b *builder) getForms(n string, ops []string) []wot.Form {
 	return []wot.Form{
 		{
 			Href:        b.GetIRI(n),
-			ContentType: "application/grpc+proto",
+			ContentType: contentTypeGrpc,
 			Op:          ops,
 		},
 	}
@@ -79,51 +196,259 @@ func (b *builder) saveToAffClass(k, n, affClass string) {
 	}
 }
 
+// affordanceName returns the name of the action or event of the RPC, which is given by the classification config or
+// the annotation of the RPC and defaults to the name of the RPC. Names of properties are ignored, e.g. of the setter
+// of a read-only property which is kept as action
+func (b *builder) affordanceName(r affs) string {
+	if c, ok := b.ac[r.Name]; ok && c.Name != "" && c.AffClass != "property" {
+		return c.Name
+	}
+	if a, ok := affordanceAnnotation(r.RPC); ok && a.name != "" && a.kind != "PROPERTY" {
+		return a.name
+	}
+	return r.Name
+}
+
+// savePropertyOp records the operation which the RPC serves on its property
+func (b *builder) savePropertyOp(k, op string) {
+	c := b.ac[k]
+	c.Op = op
+	b.ac[k] = c
+}
+
+// isScalarDataSchema determines if the DataSchema can be expanded in a URI template
+func isScalarDataSchema(ds wot.DataSchema) bool {
+	switch ds.DataType {
+	case "boolean", "integer", "number", "string":
+		return true
+	default:
+		return false
+	}
+}
+
+// getUriVariables derives the URI variables of a parameterised property from the scalar fields in the request of its
+// getter RPC. If the classification config defines UriVariables for the RPC, only these fields are used
+func (b *builder) getUriVariables(get affs) (map[string]wot.DataSchema, []string, error) {
+	if get.Req == nil || get.Req.ObjectSchema == nil || len(get.Req.Properties) == 0 {
+		return nil, nil, nil
+	}
+	names := b.ac[get.Name].UriVariables
+	if len(names) == 0 {
+		for k, v := range get.Req.Properties {
+			if isScalarDataSchema(v) {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+	uriVariables := map[string]wot.DataSchema{}
+	for _, n := range names {
+		v, ok := get.Req.Properties[n]
+		if !ok || !isScalarDataSchema(v) {
+			return nil, nil, errors.New("Configured URI variable " + n + " is not a scalar field in the request of RPC " +
+				get.Name)
+		}
+		uriVariables[n] = v
+	}
+	return uriVariables, names, nil
+}
+
+// getUriTemplate returns the form-style query expansion (RFC 6570) for the URI variables
+func getUriTemplate(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return "{?" + strings.Join(names, ",") + "}"
+}
+
+// describeAffordance sets the title and description of an affordance from the annotations of its RPCs
+func describeAffordance(ia *wot.InteractionAffordance, rpcs ...*proto.RPC) {
+	deprecated := false
+	for _, r := range rpcs {
+		if a, ok := affordanceAnnotation(r); ok {
+			if a.title != "" {
+				ia.Title = a.title
+			}
+			if a.description != "" {
+				ia.Description = a.description
+			}
+		}
+		deprecated = deprecated || hasOptionEffect(r, effectDeprecated)
+	}
+	if deprecated {
+		ia.Description = deprecatedDescription(ia.Description)
+	}
+}
+
 // saveProperty converts and saves a RPC function to a Property Affordance in the TD
+// The request of a getter is exposed through URI variables, so that parameterised properties can be read
 func (b *builder) saveProperty(p combinedProperties) {
 	affordance := wot.PropertyAffordance{}
-	var ops []string
+	a, _ := affordanceAnnotation(p.GetProp.RPC)
+	if a.readOnly && p.Category == 2 {
+		// the setter of a read-only property is kept as action
+		b.saveAction(p.SetProp)
+		p.SetProp = affs{}
+		p.Category = 0
+	}
+	getSecurity, setSecurity := b.rpcSecurity(p.GetProp), b.rpcSecurity(p.SetProp)
+	observeInterval := b.ac[p.GetProp.Name].ObserveInterval
+	interval, err := observeDuration(p.GetProp, observeInterval)
+	if err != nil {
+		b.handleError = err
+		return
+	}
+	getErrors, setErrors := b.rpcErrors(p.GetProp), b.rpcErrors(p.SetProp)
+	var uriVariables []string
 	switch p.Category {
 	case 0:
+		affordance.UriVariables, uriVariables, err = b.getUriVariables(p.GetProp)
 		b.saveToAffClass(p.GetProp.Name, p.Name, "property")
+		b.savePropertyOp(p.GetProp.Name, "readproperty")
 		affordance.DataSchema = *p.GetProp.Res
-		ops = []string{"readproperty"}
+		affordance.Forms = b.getForms(p.Name+getUriTemplate(uriVariables), []string{"readproperty"})
 	case 1:
 		b.saveToAffClass(p.SetProp.Name, p.Name, "property")
+		b.savePropertyOp(p.SetProp.Name, "writeproperty")
 		affordance.DataSchema = *p.SetProp.Req
-		ops = []string{"writeproperty"}
+		affordance.Forms = b.getForms(p.Name, []string{"writeproperty"})
 	case 2:
+		affordance.UriVariables, uriVariables, err = b.getUriVariables(p.GetProp)
 		b.saveToAffClass(p.GetProp.Name, p.Name, "property")
 		b.saveToAffClass(p.SetProp.Name, p.Name, "property")
+		b.savePropertyOp(p.GetProp.Name, "readproperty")
+		b.savePropertyOp(p.SetProp.Name, "writeproperty")
 		affordance.DataSchema = *p.GetProp.Res
-		ops = []string{"readproperty", "writeproperty"}
+		if len(uriVariables) == 0 {
+			affordance.Forms = b.getForms(p.Name, []string{"readproperty", "writeproperty"})
+		} else {
+			affordance.Forms = append(b.getForms(p.Name+getUriTemplate(uriVariables), []string{"readproperty"}),
+				b.getForms(p.Name, []string{"writeproperty"})...)
+		}
 	default:
 		return
 	}
+	if err != nil {
+		b.handleError = err
+		return
+	}
+	var forms []wot.Form
+	for _, r := range []struct {
+		rpc affs
+		op  string
+	}{{p.GetProp, "readproperty"}, {p.SetProp, "writeproperty"}} {
+		if r.rpc.RPC == nil {
+			continue
+		}
+		forms, affordance.UriVariables, err = httpForms(r.rpc, []string{r.op}, affordance.UriVariables, b.httpGatewayURL)
+		if err != nil {
+			b.handleError = err
+			return
+		}
+		affordance.Forms = append(affordance.Forms, forms...)
+	}
+	affordance.DataSchema.ReadOnly = a.readOnly
+	affordance.Forms = propertyFormSecurity(affordance.Forms, getSecurity, setSecurity)
+	b.saveSecurity(p.GetProp, getSecurity)
+	b.saveSecurity(p.SetProp, setSecurity)
+	if err := b.setFormResponses(affordance.Forms, getErrors, setErrors); err != nil {
+		b.handleError = err
+		return
+	}
+	b.saveErrors(p.GetProp, getErrors)
+	b.saveErrors(p.SetProp, setErrors)
+	describeAffordance(&affordance.InteractionAffordance, p.GetProp.RPC, p.SetProp.RPC)
+	for _, r := range []*proto.RPC{p.GetProp.RPC, p.SetProp.RPC} {
+		if a, ok := affordanceAnnotation(r); ok && a.observable {
+			affordance.Observable = true
+		}
+	}
+	if len(uriVariables) != 0 {
+		c := b.ac[p.GetProp.Name]
+		c.UriVariables = uriVariables
+		b.ac[p.GetProp.Name] = c
+	}
 
-	affordance.Forms = b.getForms(p.Name, ops)
+	if observeInterval != "" {
+		c := b.ac[p.GetProp.Name]
+		c.ObserveInterval = observeInterval
+		b.ac[p.GetProp.Name] = c
+	}
+	if p.GetProp.RPC != nil {
+		b.bind("readproperty", p.Name, p.GetProp.Name, uriVariables)
+		b.bindings[len(b.bindings)-1].ObserveInterval = interval
+	}
+	if p.SetProp.RPC != nil {
+		b.bind("writeproperty", p.Name, p.SetProp.Name, nil)
+	}
 	b.td.Properties[p.Name] = affordance
 }
 
 // saveAction converts and saves a RPC function to an Action Affordance in the TD
 func (b *builder) saveAction(r affs) {
 	affordance := wot.ActionAffordance{}
-	affordance.Input = *r.Req
-	affordance.Output = *r.Res
-	affordance.Forms = b.getForms(r.Name, []string{})
-	b.td.Actions[r.Name] = affordance
+	name := b.affordanceName(r)
+	security, errs := b.rpcSecurity(r), b.rpcErrors(r)
+	input, output := *r.Req, *r.Res
+	affordance.Input = &input
+	affordance.Output = &output
+	affordance.Safe = hasOptionEffect(r.RPC, effectSafe)
+	affordance.Idempotent = hasOptionEffect(r.RPC, effectIdempotent)
+	describeAffordance(&affordance.InteractionAffordance, r.RPC)
+	affordance.Forms = b.getForms(name, []string{"invokeaction"})
+	forms, uriVariables, err := httpForms(r, []string{"invokeaction"}, nil, b.httpGatewayURL)
+	if err != nil {
+		b.handleError = err
+		return
+	}
+	affordance.Forms, affordance.UriVariables = append(affordance.Forms, forms...), uriVariables
+	if isLongRunning(r.RPC) {
+		synchronous := false
+		affordance.Synchronous = &synchronous
+		affordance.Forms = append(affordance.Forms, b.operationForms()...)
+	}
+	setFormSecurity(affordance.Forms, security)
+	if err := b.setFormResponses(affordance.Forms, errs, nil); err != nil {
+		b.handleError = err
+		return
+	}
+	b.bind("invokeaction", name, r.Name, nil)
+	b.td.Actions[name] = affordance
 
-	b.saveToAffClass(r.Name, r.Name, "action")
+	b.saveToAffClass(r.Name, name, "action")
+	b.saveSecurity(r, security)
+	b.saveErrors(r, errs)
 }
 
 // saveEvent converts and saves a RPC function to an Event Affordance in the TD
 func (b *builder) saveEvent(r affs) {
 	affordance := wot.EventAffordance{}
-	affordance.Data = *r.Res
-	affordance.Forms = b.getForms(r.Name, []string{})
-	b.td.Events[r.Name] = affordance
+	name := b.affordanceName(r)
+	security, errs := b.rpcSecurity(r), b.rpcErrors(r)
+	data := *r.Res
+	affordance.Data = &data
+	describeAffordance(&affordance.InteractionAffordance, r.RPC)
+	affordance.Forms = b.getForms(name, []string{"subscribeevent"})
+	forms, uriVariables, err := httpForms(r, []string{"subscribeevent"}, nil, b.httpGatewayURL)
+	if err != nil {
+		b.handleError = err
+		return
+	}
+	affordance.Forms, affordance.UriVariables = append(affordance.Forms, forms...), uriVariables
+	setFormSecurity(affordance.Forms, security)
+	if err := b.setFormResponses(affordance.Forms, errs, nil); err != nil {
+		b.handleError = err
+		return
+	}
+	b.bind("subscribeevent", name, r.Name, nil)
+	b.td.Events[name] = affordance
 
-	b.saveToAffClass(r.Name, r.Name, "event")
+	b.saveToAffClass(r.Name, name, "event")
+	b.saveSecurity(r, security)
+	b.saveErrors(r, errs)
 }
 
 // readInput is a helper function to read in input from the user
@@ -160,6 +485,10 @@ func (b *builder) categorizeAffordances() {
 		fmt.Println("The following were considered as properties: ")
 	}
 	for _, v := range b.iab.affC.combinedProp {
+		if isAnnotated(v.GetProp) || isAnnotated(v.SetProp) {
+			b.saveProperty(v)
+			continue
+		}
 		t := readInput(reader, "Property", v.Name, []string{v.GetProp.Name, v.SetProp.Name})
 		switch t {
 		case "":
@@ -199,6 +528,10 @@ func (b *builder) categorizeAffordances() {
 		fmt.Println("The following were considered as actions: ")
 	}
 	for _, v := range b.iab.affC.action {
+		if isAnnotated(v) {
+			b.saveAction(v)
+			continue
+		}
 		t := readInput(reader, "Action", v.Name, []string{v.Name})
 		switch t {
 		case "":
@@ -206,7 +539,7 @@ func (b *builder) categorizeAffordances() {
 		case "a":
 			b.saveAction(v)
 		case "p":
-			if v.Req.Type == "" {
+			if !isPropertySetter(v) {
 				b.saveProperty(combinedProperties{
 					Name:     v.Name,
 					GetProp:  v,
@@ -227,6 +560,10 @@ func (b *builder) categorizeAffordances() {
 		fmt.Println("The following were considered as events: ")
 	}
 	for _, v := range b.iab.affC.event {
+		if isAnnotated(v) {
+			b.saveEvent(v)
+			continue
+		}
 		t := readInput(reader, "Event", v.Name, []string{v.Name})
 		switch t {
 		case "":
@@ -245,18 +582,14 @@ func (b *builder) categorizeAffordances() {
 	}
 }
 
-// Generates a json file to store the configurations made by classification process
-func (b *builder) generateConfigFileForAffordanceClassification(configFile string) {
-	configBytes, _ := json.Marshal(b.ac)
-	f, err := os.Create(configFile)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-	_, err = f.Write(configBytes)
-	if err != nil {
-		return
+// Generates a json file to store the configurations made by classification process, the entries follow the order of
+// the RPCs in the proto file
+func (b *builder) generateConfigFileForAffordanceClassification(configFile string, format jsonFormat) {
+	names := make([]string, 0, len(b.iab.rpcs))
+	for _, r := range b.iab.rpcs {
+		names = append(names, r.Name)
 	}
+	_ = writeJsonFileFormat(configFile, affClassConfigs{names: names, configs: b.ac}, format)
 }
 
 // saveAfterConfigRPC Saves the affordances to the TD with the classification derived from the vonfig
@@ -282,36 +615,180 @@ func contains(a []string, s string) bool {
 	return false
 }
 
-// GenerateTDfromProtoBuf parses `protoFile` to generate `tdFile`
-func GenerateTDfromProtoBuf(protoFile, outputDir, classConfigFile, ip string, port int) error { // parse the protoFile with the emicklei/proto
+// generateConfig holds the optional settings for the generation of a TD
+type generateConfig struct {
+	thingID      bool
+	warnInvalid  bool
+	securityFile string
+	grpcWebURL   string
+	httpGateway  string
+	format       jsonFormat
+}
+
+// newGenerateConfig applies the options to the default settings
+func newGenerateConfig(opts []Option) generateConfig {
+	cfg := generateConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Option configures the generation of a TD
+type Option func(*generateConfig)
+
+// WithThingID sets the id of the TD to an urn:uuid, which is stable for the fully qualified name of the service
+func WithThingID() Option {
+	return func(c *generateConfig) {
+		c.thingID = true
+	}
+}
+
+// WithSecurityConfig loads the security definitions and the security of the Thing from the JSON file,
+// which take precedence over the (wot.thing) option of the service
+func WithSecurityConfig(file string) Option {
+	return func(c *generateConfig) {
+		c.securityFile = file
+	}
+}
+
+// WithGrpcWeb adds forms targeting the gRPC-Web endpoint at the base URL to all affordances,
+// e.g. a proxy translating gRPC-Web requests of browser clients to the gRPC service
+func WithGrpcWeb(baseURL string) Option {
+	return func(c *generateConfig) {
+		c.grpcWebURL = baseURL
+	}
+}
+
+// WithHTTPGateway adds forms for the HTTP bindings of the (google.api.http) options of the RPCs, which target the
+// grpc-gateway at the base URL
+func WithHTTPGateway(baseURL string) Option {
+	return func(c *generateConfig) {
+		c.httpGateway = baseURL
+	}
+}
+
+// WithPrettyPrint indents the written JSON files
+func WithPrettyPrint() Option {
+	return func(c *generateConfig) {
+		c.format = prettyJSON
+	}
+}
+
+// WithCanonicalJSON writes the JSON files in the JSON Canonicalization Scheme (RFC 8785) and the SHA-256 hash of each
+// file next to it in <file>.sha256, which only changes if the content of the file changes
+func WithCanonicalJSON() Option {
+	return func(c *generateConfig) {
+		c.format = canonicalJSON
+	}
+}
+
+// WithValidationWarnings reports violations of the TD JSON Schema as warnings instead of failing the generation
+func WithValidationWarnings() Option {
+	return func(c *generateConfig) {
+		c.warnInvalid = true
+	}
+}
+
+// validateTD validates the TD against the TD JSON Schema, violations are returned as error or printed as warnings
+func validateTD(td wot.ThingDescription, warnInvalid bool) error {
+	tdBytes, err := json.Marshal(td)
+	if err != nil {
+		return err
+	}
+	issues, err := wot.Validate(tdBytes)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	if !warnInvalid {
+		return issues
+	}
+	for _, v := range issues {
+		fmt.Printf("Warning: the Thing Description is invalid at %s\n", v.Error())
+	}
+	return nil
+}
+
+// generate builds the TD of the proto file with the given options. If isServer is set, the RPCs are classified
+// without asking the user for confirmation
+func generate(protoFile, classConfigFile, ip string, port int, isServer bool, opts ...Option) (*builder, error) {
+	cfg := newGenerateConfig(opts)
 	configSet := true
 	// Check if config File is present
 	if _, err := os.Stat(classConfigFile); errors.Is(err, os.ErrNotExist) {
 		configSet = false
 	}
 
-	reader, _ := os.Open(protoFile)
+	reader, err := os.Open(protoFile)
+	if err != nil {
+		return nil, err
+	}
 	defer reader.Close()
 
-	b, err := fillBuilder(reader, ip, port, configSet, false, classConfigFile)
+	b, err := fillBuilder(reader, ip, port, configSet, isServer, classConfigFile, cfg.httpGateway)
+	if err != nil {
+		return nil, err
+	}
+	if isServer && !configSet {
+		// accept the proposed classification
+		b.saveAfterConfigRPC()
+		if b.handleError != nil {
+			return nil, b.handleError
+		}
+	}
+
+	if cfg.thingID {
+		b.td.ID = b.thingID()
+	}
+
+	if cfg.grpcWebURL != "" {
+		b.addGrpcWebForms(cfg.grpcWebURL)
+	}
+
+	security := securityConfig{}
+	if cfg.securityFile != "" {
+		security, err = readSecurityConfig(cfg.securityFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = b.applySecurity(security)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateTD(b.td, cfg.warnInvalid)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GenerateTDfromProtoBuf parses `protoFile` to generate `tdFile`
+func GenerateTDfromProtoBuf(protoFile, outputDir, classConfigFile, ip string, port int, opts ...Option) error { // parse the protoFile with the emicklei/proto
+	b, err := generate(protoFile, classConfigFile, ip, port, false, opts...)
 	if err != nil {
 		return err
 	}
 
-	b.generateConfigFileForAffordanceClassification(outputDir + "/classificationConfig.json")
+	format := newGenerateConfig(opts).format
+	b.generateConfigFileForAffordanceClassification(outputDir+"/classificationConfig.json", format)
 
 	// serialize the TD to JSONLD
-	tdBytes, _ := json.Marshal(b.td)
-	f, err := os.Create(outputDir + "/td.jsonld")
+	err = writeJsonFileFormat(outputDir+"/td.jsonld", b.td, format)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = f.Write(tdBytes)
+
+	// serialize the Thing Model shared by all devices implementing the proto file
+	tm, err := b.thingModel()
 	if err != nil {
 		return err
 	}
-	return nil
+	return writeJsonFileFormat(outputDir+"/td.tm.jsonld", tm, format)
 }
 
 // structure of the server response for classified affordances
@@ -346,7 +823,7 @@ type serverProp struct {
 
 // Called from /server/server.go to build parse the received proto file and return the classified affordances
 func GetProtoBufInformation(protofile io.Reader) ([]byte, error) {
-	b, err := fillBuilder(protofile, "", 0, false, true, "")
+	b, err := fillBuilder(protofile, "", 0, false, true, "", "")
 	if err != nil {
 		return []byte{}, err
 	}
@@ -413,6 +890,7 @@ func createServerDataSchema(ds *wot.DataSchema) serverDataSchema {
 			}
 			i++
 		}
+		sort.Slice(props, func(i, j int) bool { return props[i].Key < props[j].Key })
 		return serverDataSchema{
 			Type:       ds.DataType,
 			Properties: props,
@@ -422,7 +900,7 @@ func createServerDataSchema(ds *wot.DataSchema) serverDataSchema {
 }
 
 // Helper function to start the builder for server, configuration-based, and normal runs
-func fillBuilder(reader io.Reader, ip string, port int, configSet, isServer bool, classConfigFile string) (*builder, error) {
+func fillBuilder(reader io.Reader, ip string, port int, configSet, isServer bool, classConfigFile, httpGatewayURL string) (*builder, error) {
 	parser := proto.NewParser(reader)
 	definition, err := parser.Parse()
 	if err != nil {
@@ -437,9 +915,11 @@ func fillBuilder(reader io.Reader, ip string, port int, configSet, isServer bool
 
 	// initialize the TD builder with an empty TD and DataSchema
 	b := newBuilder(ip, port, dsb)
+	b.httpGatewayURL = httpGatewayURL
 
 	// translate the RPC functions into Interaction Affordances
 	proto.Walk(definition,
+		proto.WithPackage(b.HandlePackage),
 		proto.WithService(b.HandleService))
 
 	if configSet {
@@ -463,10 +943,16 @@ func fillBuilder(reader io.Reader, ip string, port int, configSet, isServer bool
 		b.saveAfterConfigRPC()
 	} else {
 		b.iab, err = generateInteractionAffordances(definition, dsb)
+		if err != nil {
+			return nil, err
+		}
 		if !isServer {
 			b.categorizeAffordances()
 		}
 	}
+	if b.handleError != nil {
+		return b, b.handleError
+	}
 
 	if b.iab == nil {
 		return b, err
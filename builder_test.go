@@ -1 +1,189 @@
 package grpcwot
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+var channelRequest = &wot.DataSchema{
+	DataType: "object",
+	ObjectSchema: &wot.ObjectSchema{
+		Properties: map[string]wot.DataSchema{
+			"channel": {DataType: "integer"},
+			"name":    {DataType: "string"},
+			"filter":  {DataType: "object"},
+		},
+	},
+}
+
+var getUriVariablesTest = []struct {
+	inAff    affs
+	inConfig map[string]affClassConfig
+	out      map[string]wot.DataSchema
+	outNames []string
+	err      error
+}{
+	{
+		affs{Name: "GetMode", Req: &wot.DataSchema{}, Res: &wot.DataSchema{}},
+		map[string]affClassConfig{},
+		nil,
+		nil,
+		nil,
+	},
+	{
+		affs{Name: "GetChannelLevel", Req: channelRequest, Res: &wot.DataSchema{}},
+		map[string]affClassConfig{},
+		map[string]wot.DataSchema{
+			"channel": {DataType: "integer"},
+			"name":    {DataType: "string"},
+		},
+		[]string{"channel", "name"},
+		nil,
+	},
+	{
+		affs{Name: "GetChannelLevel", Req: channelRequest, Res: &wot.DataSchema{}},
+		map[string]affClassConfig{
+			"GetChannelLevel": {AffClass: "property", UriVariables: []string{"channel"}},
+		},
+		map[string]wot.DataSchema{
+			"channel": {DataType: "integer"},
+		},
+		[]string{"channel"},
+		nil,
+	},
+	{
+		affs{Name: "GetChannelLevel", Req: channelRequest, Res: &wot.DataSchema{}},
+		map[string]affClassConfig{
+			"GetChannelLevel": {AffClass: "property", UriVariables: []string{"filter"}},
+		},
+		nil,
+		nil,
+		errors.New("Configured URI variable filter is not a scalar field in the request of RPC GetChannelLevel"),
+	},
+}
+
+func TestGetUriVariables(t *testing.T) {
+	for _, tt := range getUriVariablesTest {
+		b := newBuilder("127.0.0.1", 50051, nil)
+		b.ac = tt.inConfig
+		result, names, err := b.getUriVariables(tt.inAff)
+
+		errorCheck(t, tt.err, err)
+
+		if !reflect.DeepEqual(result, tt.out) {
+			t.Errorf("getUriVariables(%v) => \n%v, want \n%v", tt.inAff.Name, result, tt.out)
+		}
+		if !reflect.DeepEqual(names, tt.outNames) {
+			t.Errorf("getUriVariables(%v) => \n%v, want \n%v", tt.inAff.Name, names, tt.outNames)
+		}
+	}
+}
+
+func TestSaveParameterisedProperty(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, nil)
+	b.td.Title = "Mixer"
+	b.saveProperty(combinedProperties{
+		Name:     "ChannelLevel",
+		GetProp:  affs{Name: "GetChannelLevel", Req: channelRequest, Res: &wot.DataSchema{DataType: "object"}},
+		SetProp:  affs{Name: "SetChannelLevel", Req: &wot.DataSchema{DataType: "object"}, Res: &wot.DataSchema{}},
+		Category: 2,
+	})
+	p, ok := b.td.Properties["ChannelLevel"]
+	if !ok {
+		t.Fatalf("Expected the property ChannelLevel to be saved")
+	}
+	expectedForms := []wot.Form{
+		{
+			Href:        "Mixer/ChannelLevel{?channel,name}",
+			ContentType: "application/grpc+proto",
+			Op:          []string{"readproperty"},
+		},
+		{
+			Href:        "Mixer/ChannelLevel",
+			ContentType: "application/grpc+proto",
+			Op:          []string{"writeproperty"},
+		},
+	}
+	if !reflect.DeepEqual(p.Forms, expectedForms) {
+		t.Errorf("Expected the forms \n%v\n but got \n%v", expectedForms, p.Forms)
+	}
+	if len(p.UriVariables) != 2 {
+		t.Errorf("Expected two URI variables, but got %v", p.UriVariables)
+	}
+	if c := b.ac["GetChannelLevel"]; !reflect.DeepEqual(c.UriVariables, []string{"channel", "name"}) {
+		t.Errorf("Expected the URI variables to be saved in the classification config, but got %v", c)
+	}
+}
+
+var packageVersionTest = []struct {
+	in  string
+	out string
+}{
+	{"acme.thermostat.v1", "v1"},
+	{"acme.thermostat.v2beta1", "v2beta1"},
+	{"v3", "v3"},
+	{"acme.thermostat", ""},
+	{"acme.version", ""},
+	{"", ""},
+}
+
+func TestPackageVersion(t *testing.T) {
+	for _, tt := range packageVersionTest {
+		if result := packageVersion(tt.in); result != tt.out {
+			t.Errorf("packageVersion(%v) => %v, want %v", tt.in, result, tt.out)
+		}
+	}
+}
+
+func TestThingID(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, nil)
+	b.HandlePackage(&proto.Package{Name: "acme.thermostat.v1"})
+	b.HandleService(&proto.Service{Name: "Thermostat"})
+	id := b.thingID()
+	if !regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("Expected an urn:uuid of version 5, but got %v", id)
+	}
+	other := newBuilder("10.0.0.7", 9000, nil)
+	other.HandlePackage(&proto.Package{Name: "acme.thermostat.v1"})
+	other.HandleService(&proto.Service{Name: "Thermostat"})
+	if id != "urn:uuid:ca4b74ab-c930-549f-baa8-956de58c15de" {
+		t.Errorf("Expected the UUID version 5 of grpc:///acme.thermostat.v1.Thermostat, but got %v", id)
+	}
+	if other.thingID() != id {
+		t.Errorf("Expected the same id for the same service, but got %v and %v", id, other.thingID())
+	}
+	other.HandleService(&proto.Service{Name: "Mixer"})
+	if other.thingID() == id {
+		t.Errorf("Expected different ids for different services")
+	}
+	if b.td.Version == nil || b.td.Version.Instance != "v1" {
+		t.Errorf("Expected the version v1, but got %v", b.td.Version)
+	}
+}
+
+func TestValidateTD(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, nil)
+	b.td.Title = "Test"
+	b.td.Actions["Reset"] = wot.ActionAffordance{
+		InteractionAffordance: wot.InteractionAffordance{Forms: b.getForms("Reset", []string{"invokeaction"})},
+	}
+	if err := validateTD(b.td, false); err != nil {
+		t.Errorf("Expected a valid TD, but got %v", err)
+	}
+	b.td.Events["Changed"] = wot.EventAffordance{
+		InteractionAffordance: wot.InteractionAffordance{Forms: b.getForms("Changed", []string{"invokeaction"})},
+	}
+	err := validateTD(b.td, false)
+	var issues wot.ValidationErrors
+	if !errors.As(err, &issues) || issues[0].Pointer != "/events/Changed/forms/0/op" {
+		t.Errorf("Expected an invalid op of the event Changed, but got %v", err)
+	}
+	if err := validateTD(b.td, true); err != nil {
+		t.Errorf("Expected only warnings, but got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/urfave/cli/v2"
+)
+
+// loadThingDescription reads a TD, a proto file is translated into its TD with the classification config
+func loadThingDescription(file, classConfigFile string) (wot.ThingDescription, error) {
+	if strings.HasSuffix(file, ".proto") {
+		if _, err := os.Stat(file); err != nil {
+			return wot.ThingDescription{}, err
+		}
+		td, _, err := grpcwot.GenerateBindings(file, classConfigFile, "127.0.0.1", 50051)
+		return td, err
+	}
+	var td wot.ThingDescription
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return td, err
+	}
+	if err := json.Unmarshal(b, &td); err != nil {
+		return td, fmt.Errorf("%s is no Thing Description: %s", file, err)
+	}
+	return td, nil
+}
+
+// diffThingDescriptions reports the changes between two revisions of a TD or a proto file and
+// fails if any change breaks the consumers of the old revision
+func diffThingDescriptions(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.Exit("diff takes the old and the new revision", 2)
+	}
+	old, err := loadThingDescription(c.Args().Get(0), c.String("old-config"))
+	if err != nil {
+		return err
+	}
+	new, err := loadThingDescription(c.Args().Get(1), c.String("new-config"))
+	if err != nil {
+		return err
+	}
+	changes := wot.Diff(old, new)
+	if c.Bool("json") {
+		if changes == nil {
+			changes = wot.Changes{}
+		}
+		b, err := json.MarshalIndent(struct {
+			Breaking bool        `json:"breaking"`
+			Changes  wot.Changes `json:"changes"`
+		}{changes.Breaking(), changes}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	} else {
+		breaking := 0
+		for _, v := range changes {
+			fmt.Println(v)
+			if v.Breaking {
+				breaking++
+			}
+		}
+		fmt.Printf("%d changes, %d breaking\n", len(changes), breaking)
+	}
+	if changes.Breaking() {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/export"
+	"github.com/urfave/cli/v2"
+)
+
+// exportAPI writes the OpenAPI or AsyncAPI document of the gateway serving the proto file
+func exportAPI(c *cli.Context) error {
+	protoFile := c.Args().Get(0)
+	if _, err := os.Stat(protoFile); err != nil {
+		return err
+	}
+	var opts []grpcwot.Option
+	if c.String("security") != "" {
+		opts = append(opts, grpcwot.WithSecurityConfig(c.String("security")))
+	}
+	td, bindings, err := grpcwot.GenerateBindings(protoFile, c.String("config"), "127.0.0.1", 50051, opts...)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	switch c.String("format") {
+	case "openapi":
+		doc, err = export.OpenAPI(td, bindings, c.String("server"))
+	case "asyncapi":
+		doc, err = export.AsyncAPI(td, bindings, c.String("server"))
+	default:
+		return fmt.Errorf("unknown format %s, expected openapi or asyncapi", c.String("format"))
+	}
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if c.String("output") == "" {
+		fmt.Println(string(b))
+		return nil
+	}
+	return ioutil.WriteFile(c.String("output"), b, 0644)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/gateway"
+	"github.com/Interactions-HSG/grpcwot/pkg/protofmt"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// serveGateway serves the TD of the proto file at /.well-known/wot and forwards the interactions to the gRPC service
+func serveGateway(c *cli.Context) error {
+	protoFile := c.Args().Get(0)
+	var opts []grpcwot.Option
+	if c.String("security") != "" {
+		opts = append(opts, grpcwot.WithSecurityConfig(c.String("security")))
+	}
+	td, bindings, err := grpcwot.GenerateBindings(protoFile, c.String("config"), c.String("ip"), c.Int("port"), opts...)
+	if err != nil {
+		return err
+	}
+	fd, err := protofmt.ParseProtoFile(protoFile, c.StringSlice("proto_path")...)
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(fmt.Sprintf("%s:%d", c.String("ip"), c.Int("port")),
+		grpc.WithTransportCredentials(transportCredentials(td.Base)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	g, err := gateway.New(fd, td, bindings, conn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Serving the Thing Description at http://%s%s\n", c.String("listen"), gateway.WellKnownPath)
+	return http.ListenAndServe(c.String("listen"), g)
+}
+
+// transportCredentials returns the credentials to connect to the gRPC service at the base of the TD, which is https if
+// the security of the Thing requires TLS
+func transportCredentials(base string) credentials.TransportCredentials {
+	if strings.HasPrefix(base, "https://") {
+		return credentials.NewTLS(nil)
+	}
+	return insecure.NewCredentials()
+}
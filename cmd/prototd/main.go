@@ -2,11 +2,15 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/mock"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
 	"github.com/urfave/cli/v2"
 )
 
@@ -36,10 +40,316 @@ func main() {
 				Value:   "",
 				Usage:   "Load a configuration for affordance classification",
 			},
+			&cli.StringFlag{
+				Name:  "security",
+				Value: "",
+				Usage: "Load the security definitions and the security of the Thing from `FILE`",
+			},
+			&cli.BoolFlag{
+				Name:  "id",
+				Usage: "Set the id of the Thing Description to an urn:uuid derived from the service name",
+			},
+			&cli.StringFlag{
+				Name:  "grpc-web",
+				Value: "",
+				Usage: "Add gRPC-Web forms targeting the gRPC-Web endpoint at `URL`, e.g. http://127.0.0.1:8080",
+			},
+			&cli.StringFlag{
+				Name:  "http-gateway",
+				Value: "",
+				Usage: "Add HTTP forms for the (google.api.http) options targeting the grpc-gateway at `URL`, e.g. http://127.0.0.1:8081",
+			},
+			&cli.BoolFlag{
+				Name:  "warn-invalid",
+				Usage: "Write the Thing Description with warnings instead of failing if it violates the TD JSON Schema",
+			},
+			&cli.BoolFlag{
+				Name:  "pretty",
+				Usage: "Indent the written JSON files",
+			},
+			&cli.BoolFlag{
+				Name:  "canonical",
+				Usage: "Write the JSON files canonicalized with RFC 8785 and their SHA-256 hashes to <file>.sha256",
+			},
 		},
 		Name:  "prototd",
 		Usage: "Translate ProtocolBuffers to ThingDescription",
+		Commands: []*cli.Command{
+			{
+				Name:      "serve-gateway",
+				Usage:     "Serve the Thing Description and translate its interactions into calls of the gRPC service",
+				ArgsUsage: "<input.proto>",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "port",
+						Aliases: []string{"p"},
+						Value:   50051,
+						Usage:   "The port for the gRPC service",
+					},
+					&cli.StringFlag{
+						Name:  "ip",
+						Value: "127.0.0.1",
+						Usage: "The IP address for the gRPC service",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Value:   "",
+						Usage:   "Load a configuration for affordance classification",
+					},
+					&cli.StringFlag{
+						Name:  "security",
+						Value: "",
+						Usage: "Load the security definitions and the security of the Thing from `FILE`",
+					},
+					&cli.StringSliceFlag{
+						Name:    "proto_path",
+						Aliases: []string{"I"},
+						Usage:   "Resolve the imports of the proto file in `DIR`",
+					},
+					&cli.StringFlag{
+						Name:  "listen",
+						Value: ":8080",
+						Usage: "Serve the gateway at `ADDRESS`",
+					},
+				},
+				Action: serveGateway,
+			},
+			{
+				Name:      "mock",
+				Usage:     "Serve a mock of the gRPC service which responds with samples of the data schemas of the Thing Description",
+				ArgsUsage: "<input.proto>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Value:   "",
+						Usage:   "Load a configuration for affordance classification",
+					},
+					&cli.StringSliceFlag{
+						Name:    "proto_path",
+						Aliases: []string{"I"},
+						Usage:   "Resolve the imports of the proto file in `DIR`",
+					},
+					&cli.StringFlag{
+						Name:  "listen",
+						Value: "127.0.0.1:50051",
+						Usage: "Serve the gRPC service at `ADDRESS`",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Value: mock.DefaultInterval,
+						Usage: "Emit the events in the `INTERVAL`",
+					},
+				},
+				Action: serveMock,
+			},
+			{
+				Name:      "validate",
+				Usage:     "Validate a Thing Description against the TD JSON Schema",
+				ArgsUsage: "<td.jsonld>",
+				Action: func(c *cli.Context) error {
+					td, err := ioutil.ReadFile(c.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					issues, err := wot.Validate(td)
+					if err != nil {
+						return err
+					}
+					for _, v := range issues {
+						fmt.Println(v.Error())
+					}
+					if len(issues) != 0 {
+						return cli.Exit(fmt.Sprintf("%s is not a valid Thing Description", c.Args().Get(0)), 1)
+					}
+					fmt.Printf("%s is a valid Thing Description\n", c.Args().Get(0))
+					return nil
+				},
+			},
+			{
+				Name:      "instantiate",
+				Usage:     "Create the Thing Description of a device from a Thing Model",
+				ArgsUsage: "<td.tm.jsonld>",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "port",
+						Aliases: []string{"p"},
+						Value:   50051,
+						Usage:   "The port for the gRPC service of the device",
+					},
+					&cli.StringFlag{
+						Name:  "ip",
+						Value: "127.0.0.1",
+						Usage: "The IP address for the gRPC service of the device",
+					},
+					&cli.StringFlag{
+						Name:    "outputDir",
+						Aliases: []string{"o"},
+						Value:   "output",
+						Usage:   "Write the resulting Thing Description to `DIR`",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					tmFile := c.Args().Get(0)
+					if _, err := os.Stat(tmFile); err != nil {
+						return err
+					}
+					return grpcwot.InstantiateThingModel(
+						tmFile,
+						c.String("outputDir"),
+						c.String("ip"),
+						c.Int("port"))
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "Report the changes between two revisions of a proto file or a Thing Description and fail on breaking changes",
+				ArgsUsage: "<old.proto|old.jsonld> <new.proto|new.jsonld>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "old-config",
+						Usage: "Classify the RPCs of the old proto file with the configuration in `FILE`",
+					},
+					&cli.StringFlag{
+						Name:  "new-config",
+						Usage: "Classify the RPCs of the new proto file with the configuration in `FILE`",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Write the changes as JSON",
+					},
+				},
+				Action: diffThingDescriptions,
+			},
+			{
+				Name:      "export",
+				Usage:     "Describe the HTTP surface of the gateway as OpenAPI document or its event streams as AsyncAPI document",
+				ArgsUsage: "<input.proto>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "openapi",
+						Usage: "Export the document in `FORMAT`, openapi or asyncapi",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Value:   "",
+						Usage:   "Load a configuration for affordance classification",
+					},
+					&cli.StringFlag{
+						Name:  "security",
+						Value: "",
+						Usage: "Load the security definitions and the security of the Thing from `FILE`",
+					},
+					&cli.StringFlag{
+						Name:  "server",
+						Value: "http://127.0.0.1:8080",
+						Usage: "The `URL` of the gateway",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Write the document to `FILE` instead of the standard output",
+					},
+				},
+				Action: exportAPI,
+			},
+			{
+				Name:      "docs",
+				Usage:     "Render the Thing Description of a proto file as Markdown or HTML documentation",
+				ArgsUsage: "<input.proto>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: grpcwot.DocsMarkdown,
+						Usage: "Render the documentation in `FORMAT`, markdown or html",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Value:   "",
+						Usage:   "Load a configuration for affordance classification",
+					},
+					&cli.StringFlag{
+						Name:  "security",
+						Value: "",
+						Usage: "Load the security definitions and the security of the Thing from `FILE`",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Write the documentation to `FILE` instead of the standard output",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					protoFile := c.Args().Get(0)
+					if _, err := os.Stat(protoFile); err != nil {
+						return err
+					}
+					var opts []grpcwot.Option
+					if c.String("security") != "" {
+						opts = append(opts, grpcwot.WithSecurityConfig(c.String("security")))
+					}
+					docs, err := grpcwot.GenerateDocs(protoFile, c.String("config"), c.String("format"), opts...)
+					if err != nil {
+						return err
+					}
+					if c.String("output") == "" {
+						fmt.Print(docs)
+						return nil
+					}
+					return ioutil.WriteFile(c.String("output"), []byte(docs), 0644)
+				},
+			},
+			{
+				Name:      "reverse",
+				Usage:     "Derive a proto file and its classification config from a Thing Description",
+				ArgsUsage: "<td.jsonld>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "outputDir",
+						Aliases: []string{"o"},
+						Value:   "output",
+						Usage:   "Write the proto file and the classification config to `DIR`",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					tdFile := c.Args().Get(0)
+					if _, err := os.Stat(tdFile); err != nil {
+						return err
+					}
+					return grpcwot.GenerateProtoBufFromTD(tdFile, c.String("outputDir"))
+				},
+			},
+		},
 		Action: func(c *cli.Context) error {
+			var opts []grpcwot.Option
+			if c.Bool("id") {
+				opts = append(opts, grpcwot.WithThingID())
+			}
+			if c.String("security") != "" {
+				opts = append(opts, grpcwot.WithSecurityConfig(c.String("security")))
+			}
+			if c.String("grpc-web") != "" {
+				opts = append(opts, grpcwot.WithGrpcWeb(c.String("grpc-web")))
+			}
+			if c.String("http-gateway") != "" {
+				opts = append(opts, grpcwot.WithHTTPGateway(c.String("http-gateway")))
+			}
+			if c.Bool("warn-invalid") {
+				opts = append(opts, grpcwot.WithValidationWarnings())
+			}
+			if c.Bool("pretty") && c.Bool("canonical") {
+				return errors.New("the flags --pretty and --canonical are mutually exclusive")
+			}
+			if c.Bool("pretty") {
+				opts = append(opts, grpcwot.WithPrettyPrint())
+			}
+			if c.Bool("canonical") {
+				opts = append(opts, grpcwot.WithCanonicalJSON())
+			}
 			protoFile := c.Args().Get(0)
 			if !strings.HasSuffix(protoFile, ".proto") {
 				return errors.New("the input file must be a .proto file")
@@ -51,7 +361,8 @@ func main() {
 				c.String("outputDir"),
 				c.String("config"),
 				c.String("ip"),
-				c.Int("port"))
+				c.Int("port"),
+				opts...)
 		},
 	}
 
@@ -1,17 +1,39 @@
 package main
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Interactions-HSG/grpcwot"
 )
 
+// testOptions returns the classification config and the options of a test directory,
+// a config.json is used for the classification, a security.json for the security of the Thing and the URL in
+// http-gateway.txt as base URL of the grpc-gateway
+func testOptions(dir string) (string, []grpcwot.Option) {
+	configFile := filepath.Join(dir, "config.json")
+	if _, err := os.Stat(configFile); err != nil {
+		configFile = ""
+	}
+	var opts []grpcwot.Option
+	securityFile := filepath.Join(dir, "security.json")
+	if _, err := os.Stat(securityFile); err == nil {
+		opts = append(opts, grpcwot.WithSecurityConfig(securityFile))
+	}
+	if url, err := ioutil.ReadFile(filepath.Join(dir, "http-gateway.txt")); err == nil {
+		opts = append(opts, grpcwot.WithHTTPGateway(strings.TrimSpace(string(url))))
+	}
+	return configFile, opts
+}
+
 // TestProtoToTD runs over the test proto files in ./test/*/input.proto and compare the result
-// with output.jsonld in the same directory
+// with output.jsonld in the same directory. If a config.json is present, it is used for the classification. If a
+// classificationConfig.json is present, the written classification config is compared with it
 func TestProtoToTD(t *testing.T) {
 	testDir := "./test"
 	tests, err := ioutil.ReadDir(testDir)
@@ -21,13 +43,13 @@ func TestProtoToTD(t *testing.T) {
 	for _, f := range tests {
 		inputFile := filepath.Join(testDir, f.Name(), "input.proto")
 		outputFile := filepath.Join(testDir, f.Name(), "output.jsonld")
-		tmpFile, err := ioutil.TempFile(testDir, "result.jsonld")
+		configFile, opts := testOptions(filepath.Join(testDir, f.Name()))
+		tmpDir := t.TempDir()
+		err := grpcwot.GenerateTDfromProtoBuf(inputFile, tmpDir, configFile, "127.0.0.1", 50051, opts...)
 		if err != nil {
 			t.Error(err)
 		}
-		defer os.Remove(tmpFile.Name())
-		grpcwot.GenerateTDfromProtoBuf(inputFile, tmpFile.Name(), "127.0.0.1", 50051)
-		result, err := ioutil.ReadFile(tmpFile.Name())
+		result, err := ioutil.ReadFile(filepath.Join(tmpDir, "td.jsonld"))
 		if err != nil {
 			t.Error(err)
 		}
@@ -35,8 +57,73 @@ func TestProtoToTD(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
+		if !reflect.DeepEqual(result, out) {
+			t.Errorf("%v => \n%s, want \n%s", inputFile, result, out)
+		}
+		classificationFile := filepath.Join(testDir, f.Name(), "classificationConfig.json")
+		if expected, err := ioutil.ReadFile(classificationFile); err == nil {
+			result, err := ioutil.ReadFile(filepath.Join(tmpDir, "classificationConfig.json"))
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("%v => \n%s, want the classification config \n%s", inputFile, result, expected)
+			}
+		}
+	}
+}
+
+// TestThingModelToTD instantiates the Thing Models generated from the test proto files with the address
+// used for output.jsonld and expects the same affordances as in output.jsonld and the version of the model
+func TestThingModelToTD(t *testing.T) {
+	testDir := "./test"
+	tests, err := ioutil.ReadDir(testDir)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, f := range tests {
+		inputFile := filepath.Join(testDir, f.Name(), "input.proto")
+		configFile, opts := testOptions(filepath.Join(testDir, f.Name()))
+		tmpDir := t.TempDir()
+		err := grpcwot.GenerateTDfromProtoBuf(inputFile, tmpDir, configFile, "127.0.0.1", 50051, opts...)
+		if err != nil {
+			t.Error(err)
+		}
+		instanceDir := t.TempDir()
+		err = grpcwot.InstantiateThingModel(filepath.Join(tmpDir, "td.tm.jsonld"), instanceDir, "127.0.0.1", 50051)
+		if err != nil {
+			t.Error(err)
+		}
+		var result, out map[string]interface{}
+		for file, v := range map[string]*map[string]interface{}{
+			filepath.Join(instanceDir, "td.jsonld"):           &result,
+			filepath.Join(testDir, f.Name(), "output.jsonld"): &out,
+		} {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Error(err)
+			}
+			if err := json.Unmarshal(b, v); err != nil {
+				t.Error(err)
+			}
+		}
+		delete(result, "@context")
+		delete(out, "@context")
+		// the instance keeps the version of the package as version of its model
+		if version, ok := out["version"].(map[string]interface{}); ok {
+			version["model"] = version["instance"]
+		}
 		if !reflect.DeepEqual(result, out) {
 			t.Errorf("%v => \n%v, want \n%v", inputFile, result, out)
 		}
 	}
 }
+
+func TestTransportCredentials(t *testing.T) {
+	if p := transportCredentials("https://127.0.0.1:50051/").Info().SecurityProtocol; p != "tls" {
+		t.Errorf("Expected TLS for a https base, but got %s", p)
+	}
+	if p := transportCredentials("http://127.0.0.1:50051/").Info().SecurityProtocol; p != "insecure" {
+		t.Errorf("Expected no TLS for a http base, but got %s", p)
+	}
+}
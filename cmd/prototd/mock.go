@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/mock"
+	"github.com/Interactions-HSG/grpcwot/pkg/protofmt"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+)
+
+// serveMock serves a mock of the gRPC service of the proto file, whose responses are samples of the data schemas of its TD
+func serveMock(c *cli.Context) error {
+	protoFile := c.Args().Get(0)
+	lis, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return err
+	}
+	defer lis.Close()
+	host, p, err := net.SplitHostPort(lis.Addr().String())
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return err
+	}
+	td, bindings, err := grpcwot.GenerateBindings(protoFile, c.String("config"), host, port)
+	if err != nil {
+		return err
+	}
+	fd, err := protofmt.ParseProtoFile(protoFile, c.StringSlice("proto_path")...)
+	if err != nil {
+		return err
+	}
+	m, err := mock.New(fd, td, bindings, mock.WithInterval(c.Duration("interval")))
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	m.Register(server)
+	fmt.Printf("Serving the mock of %s at %s\n", td.Title, lis.Addr())
+	return server.Serve(lis)
+}
@@ -3,8 +3,10 @@ package grpcwot
 import (
 	"errors"
 	"github.com/Interactions-HSG/grpcwot/pkg/protofmt"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
 	"github.com/emicklei/proto"
-	"github.com/linksmart/thing-directory/wot"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +14,9 @@ type refMesTuple struct {
 	pm string // Parent message where the field of type message is included
 	t  string // Type of the field == name of the referenced message
 	n  string // name of the field
+	o  string // name of the oneof including the field, empty if the field is not an alternative of a oneof
+	// nullable marks a proto3 optional field, which becomes nullable if the referenced type is an enum
+	nullable bool
 }
 
 type dataSchemaBuilder struct {
@@ -35,10 +40,60 @@ func getFullMessageName(m *proto.Message) string {
 	}
 }
 
+// isProto3 determines if the message is defined in a proto file with the syntax proto3
+func isProto3(m *proto.Message) bool {
+	var parent proto.Visitee = m
+	for parent != nil {
+		switch v := parent.(type) {
+		case *proto.Message:
+			parent = v.Parent
+		case *proto.Proto:
+			for _, e := range v.Elements {
+				if s, ok := e.(*proto.Syntax); ok {
+					return s.Value == "proto3"
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// messageNames returns the full names of the messages and enums in alphabetical order
+func (b *dataSchemaBuilder) messageNames() []string {
+	names := make([]string, 0, len(b.ds))
+	for k := range b.ds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // HandleMessage build a DataSchema: https://www.w3.org/TR/wot-thing-description/#dataschema
 // from a Message in the protobuf definition
 func (b *dataSchemaBuilder) HandleMessage(m *proto.Message) {
-	fullMessageName := getFullMessageName(m)
+	b.handleElements(getFullMessageName(m), m.Elements, isProto3(m))
+}
+
+// HandleEnum builds the DataSchema of an enum, which is a string holding the name of one of its values
+func (b *dataSchemaBuilder) HandleEnum(e *proto.Enum) {
+	name := e.Name
+	if m, ok := e.Parent.(*proto.Message); ok {
+		name = getFullMessageName(m) + "." + e.Name
+	}
+	ds := &wot.DataSchema{DataType: "string", Enum: []interface{}{}}
+	for _, v := range e.Elements {
+		if f, ok := v.(*proto.EnumField); ok {
+			ds.Enum = append(ds.Enum, f.Name)
+		}
+	}
+	b.ds[name] = ds
+}
+
+// handleElements adds the fields of a message or a group to the DataSchema of the given full message name
+func (b *dataSchemaBuilder) handleElements(fullMessageName string, elements []proto.Visitee, proto3 bool) {
 	if _, ok := b.ds[fullMessageName]; !ok {
 		b.ds[fullMessageName] = &wot.DataSchema{
 			DataType: "object",
@@ -47,11 +102,26 @@ func (b *dataSchemaBuilder) HandleMessage(m *proto.Message) {
 			},
 		}
 	}
-	for _, v := range m.Elements {
+	for _, v := range elements {
 		switch protofmt.NameOfVisitee(v) {
 		case "NormalField":
-			b.ds[fullMessageName].ObjectSchema.Properties[v.(*proto.NormalField).Field.Name] =
-				b.fieldToDataSchema(v.(*proto.NormalField).Field, fullMessageName)
+			f := v.(*proto.NormalField)
+			refs := len(b.lm)
+			ds := b.normalFieldToDataSchema(f, fullMessageName)
+			if proto3 && f.Optional {
+				if len(b.lm) > refs {
+					// the type is only known after resolving the reference, messages keep their presence
+					b.lm[refs].nullable = true
+				} else {
+					ds = nullableDataSchema(ds)
+				}
+			}
+			b.ds[fullMessageName].ObjectSchema.Properties[f.Name] = ds
+			if f.Required || isRequiredByRules(validationRules(f.Field)) {
+				b.ds[fullMessageName].ObjectSchema.Required = append(b.ds[fullMessageName].ObjectSchema.Required, f.Name)
+			}
+		case "Group":
+			b.handleGroup(v.(*proto.Group), fullMessageName)
 		case "Comment":
 		case "Oneof":
 			b.ds[fullMessageName].ObjectSchema.Properties[v.(*proto.Oneof).Name] =
@@ -60,6 +130,54 @@ func (b *dataSchemaBuilder) HandleMessage(m *proto.Message) {
 	}
 }
 
+// handleGroup builds the DataSchema of a proto2 group like a nested message,
+// the field holding the group is named by the lowercased group name
+func (b *dataSchemaBuilder) handleGroup(g *proto.Group, messageName string) {
+	fieldName := strings.ToLower(g.Name)
+	b.handleElements(messageName+"."+g.Name, g.Elements, false)
+	b.lm = append(b.lm, refMesTuple{pm: messageName, t: g.Name, n: fieldName})
+	ds := wot.DataSchema{DataType: "object"}
+	if g.Repeated {
+		items := ds
+		ds = wot.DataSchema{DataType: "array", ArraySchema: &wot.ArraySchema{Items: &items}}
+	}
+	b.ds[messageName].ObjectSchema.Properties[fieldName] = ds
+	if g.Required {
+		b.ds[messageName].ObjectSchema.Required = append(b.ds[messageName].ObjectSchema.Required, fieldName)
+	}
+}
+
+// nullableDataSchema marks a field with explicit presence (proto3 optional) as nullable,
+// which distinguishes an unset field from a field with implicit presence holding its default value
+func nullableDataSchema(ds wot.DataSchema) wot.DataSchema {
+	n := wot.DataSchema{
+		Title:       ds.Title,
+		Description: ds.Description,
+		OneOf:       []wot.DataSchema{ds, {DataType: "null"}},
+	}
+	n.OneOf[0].Title, n.OneOf[0].Description = "", ""
+	return n
+}
+
+// defaultValue interprets the literal of a proto2 default option according to the type of the field
+func defaultValue(l *proto.Literal, fieldType string) interface{} {
+	switch fieldType {
+	case "number":
+		if f, ok := literalFloat(l); ok {
+			return f
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(l.Source, 0, 64); err == nil {
+			return i
+		}
+	case "boolean":
+		return literalBool(l)
+	default:
+		return literalString(l)
+	}
+	return nil
+}
+
 // fieldToDataSchema converts the given proto's message field into a WoT DataScheme
 // cf. https://www.w3.org/TR/wot-thing-description/#dataschema
 func (b *dataSchemaBuilder) fieldToDataSchema(f *proto.Field, messageName string) wot.DataSchema {
@@ -77,13 +195,56 @@ func (b *dataSchemaBuilder) fieldToDataSchema(f *proto.Field, messageName string
 		fieldType = "object"
 		b.lm = append(b.lm, refMesTuple{pm: messageName, t: f.Type, n: f.Name})
 	}
-	return wot.DataSchema{DataType: fieldType}
+	ds := wot.DataSchema{DataType: fieldType}
+	if o, ok := findOption(f.Options, "default"); ok {
+		ds.Default = defaultValue(&o.Constant, fieldType)
+	}
+	applyValidationRules(&ds, validationRules(f))
+	applyFieldDirectives(&ds, f)
+	applyFieldOption(&ds, f)
+	return ds
+}
+
+// normalFieldToDataSchema converts a message field into a DataSchema, where repeated fields become arrays
+func (b *dataSchemaBuilder) normalFieldToDataSchema(f *proto.NormalField, messageName string) wot.DataSchema {
+	ds := b.fieldToDataSchema(f.Field, messageName)
+	if !f.Repeated {
+		return ds
+	}
+	arr := wot.DataSchema{
+		Title:       ds.Title,
+		Description: ds.Description,
+		DataType:    "array",
+		ArraySchema: &wot.ArraySchema{Items: &ds},
+	}
+	ds.Title, ds.Description = "", ""
+	applyValidationRules(&arr, validationRules(f.Field))
+	return arr
 }
 
+// oneofToDataSchema converts the alternatives of a oneof into object schemas keyed by the name of the field,
+// which matches the JSON encoding of proto3 where only the field of the set alternative is present
 func (b *dataSchemaBuilder) oneofToDataSchema(oo *proto.Oneof, messageName string) []wot.DataSchema {
 	oof := []wot.DataSchema{}
 	for _, v := range oo.Elements {
-		oof = append(oof, b.fieldToDataSchema(v.(*proto.OneOfField).Field, messageName))
+		f, ok := v.(*proto.OneOfField)
+		if !ok {
+			continue
+		}
+		refs := len(b.lm)
+		ds := b.fieldToDataSchema(f.Field, messageName)
+		if len(b.lm) > refs {
+			// the alternative references a message, which is resolved into the branch of the oneof
+			b.lm[refs].o = oo.Name
+		}
+		oof = append(oof, wot.DataSchema{
+			Title:    f.Name,
+			DataType: "object",
+			ObjectSchema: &wot.ObjectSchema{
+				Properties: map[string]wot.DataSchema{f.Name: ds},
+				Required:   []string{f.Name},
+			},
+		})
 	}
 	return oof
 }
@@ -162,18 +323,48 @@ func (b *dataSchemaBuilder) constructMessagesNested() error {
 	}
 
 	for _, v := range b.lm {
-		b.ds[v.pm].ObjectSchema.Properties[v.n] = *b.ds[v.t]
+		nested := *b.ds[v.t]
+		if v.o != "" {
+			// alternative of a oneof, the referenced message is set in the branch of the field
+			for _, branch := range b.ds[v.pm].ObjectSchema.Properties[v.o].OneOf {
+				if branch.Title == v.n {
+					branch.ObjectSchema.Properties[v.n] = referencedDataSchema(branch.ObjectSchema.Properties[v.n], nested)
+				}
+			}
+		} else if p := b.ds[v.pm].ObjectSchema.Properties[v.n]; p.ArraySchema != nil {
+			// repeated field, the referenced message defines the items of the array
+			items := referencedDataSchema(*p.Items, nested)
+			p.Items = &items
+		} else {
+			ds := referencedDataSchema(p, nested)
+			if v.nullable && nested.Enum != nil {
+				ds = nullableDataSchema(ds)
+			}
+			b.ds[v.pm].ObjectSchema.Properties[v.n] = ds
+		}
 	}
 	return nil
 }
 
+// referencedDataSchema returns the DataSchema of a field referencing a message or an enum. Messages replace the
+// DataSchema of the field, whereas enums keep the annotations of the field, e.g. its description or default value
+func referencedDataSchema(field, referenced wot.DataSchema) wot.DataSchema {
+	if referenced.Enum == nil {
+		return referenced
+	}
+	field.DataType = referenced.DataType
+	field.Enum = referenced.Enum
+	return field
+}
+
 // Walks the proto files messages and generates the data schemes
 // In case of an invalid proto file an error is raised
 func generateDataSchemas(protoFile *proto.Proto) (*dataSchemaBuilder, error) {
 	b := newDataSchemaBuilder()
 
 	proto.Walk(protoFile,
-		proto.WithMessage(b.HandleMessage))
+		proto.WithMessage(b.HandleMessage),
+		proto.WithEnum(b.HandleEnum))
 
 	err := b.constructMessagesNested()
 
@@ -2,10 +2,12 @@ package grpcwot
 
 import (
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
 	"github.com/emicklei/proto"
-	"github.com/linksmart/thing-directory/wot"
 )
 
 // Message parsing test for scalar value fields in fieldToDataSchema()
@@ -527,3 +529,137 @@ func TestConstructMessageNested(t *testing.T) {
 		}
 	}
 }
+
+func TestFieldPresence(t *testing.T) {
+	b := newDataSchemaBuilder()
+	b.HandleMessage(parseTestMessage(t, `syntax = "proto3";
+message Test {
+  optional int32 level = 1;
+  int32 count = 2;
+}`))
+	level := b.ds["Test"].Properties["level"]
+	if len(level.OneOf) != 2 || level.OneOf[0].DataType != "integer" || level.OneOf[1].DataType != "null" {
+		t.Errorf("Expected the optional field level to be nullable, but got %v", level)
+	}
+	if count := b.ds["Test"].Properties["count"]; count.DataType != "integer" || count.OneOf != nil {
+		t.Errorf("Expected the field count to be an integer, but got %v", count)
+	}
+
+	b = newDataSchemaBuilder()
+	def, err := proto.NewParser(strings.NewReader(`syntax = "proto3";
+enum Mode {
+  MODE_UNSPECIFIED = 0;
+  ECO = 1;
+}
+message Limit {
+  int32 max = 1;
+}
+message Test {
+  optional Mode mode = 1;
+  optional Limit limit = 2;
+}`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto.Walk(def, proto.WithEnum(b.HandleEnum), proto.WithMessage(b.HandleMessage))
+	if err := b.constructMessagesNested(); err != nil {
+		t.Fatal(err)
+	}
+	mode := b.ds["Test"].Properties["mode"]
+	if len(mode.OneOf) != 2 || len(mode.OneOf[0].Enum) != 2 || mode.OneOf[1].DataType != "null" {
+		t.Errorf("Expected the optional enum field mode to be nullable, but got %v", mode)
+	}
+	if limit := b.ds["Test"].Properties["limit"]; limit.DataType != "object" || limit.OneOf != nil {
+		t.Errorf("Expected the optional message field limit to be an object, but got %v", limit)
+	}
+
+	b = newDataSchemaBuilder()
+	b.HandleMessage(parseTestMessage(t, `syntax = "proto2";
+message Test {
+  required int32 level = 1;
+  optional double factor = 2 [default = 0.5];
+  optional group Limit = 3 {
+    optional int32 max = 4;
+  }
+}`))
+	if r := b.ds["Test"].Required; len(r) != 1 || r[0] != "level" {
+		t.Errorf("Expected the required field level, but got %v", r)
+	}
+	if factor := b.ds["Test"].Properties["factor"]; factor.Default != 0.5 || factor.OneOf != nil {
+		t.Errorf("Expected the default 0.5 for the field factor, but got %v", factor)
+	}
+	if _, ok := b.ds["Test.Limit"].Properties["max"]; !ok {
+		t.Errorf("Expected the group Limit to be handled as nested message, but got %v", b.ds["Test.Limit"])
+	}
+	if len(b.lm) != 1 || b.lm[0] != (refMesTuple{pm: "Test", t: "Limit", n: "limit"}) {
+		t.Errorf("Expected a reference to the group Limit, but got %v", b.lm)
+	}
+}
+
+func TestOneofToDataSchema(t *testing.T) {
+	b := newDataSchemaBuilder()
+	b.HandleMessage(parseTestMessage(t, `syntax = "proto3";
+message Test {
+  oneof target {
+    string name = 1;
+    Selector selector = 2;
+  }
+}`))
+	b.ds["Selector"] = &wot.DataSchema{DataType: "object", ObjectSchema: &wot.ObjectSchema{
+		Properties: map[string]wot.DataSchema{"room": {DataType: "string"}},
+	}}
+	if err := b.constructMessagesNested(); err != nil {
+		t.Fatal(err)
+	}
+	branches := b.ds["Test"].Properties["target"].OneOf
+	if len(branches) != 2 {
+		t.Fatalf("Expected two alternatives of the oneof target, but got %v", branches)
+	}
+	if branches[0].Title != "name" || branches[0].Properties["name"].DataType != "string" ||
+		len(branches[0].Required) != 1 || branches[0].Required[0] != "name" {
+		t.Errorf("Expected the alternative name of type string, but got %v", branches[0])
+	}
+	selector := branches[1].Properties["selector"]
+	if branches[1].Title != "selector" || selector.ObjectSchema == nil || selector.Properties["room"].DataType != "string" {
+		t.Errorf("Expected the alternative selector to reference the message Selector, but got %v", branches[1])
+	}
+	if _, ok := b.ds["Test"].Properties["selector"]; ok {
+		t.Errorf("Expected the alternative selector not to be a property of the message Test")
+	}
+}
+
+func TestEnumDataSchema(t *testing.T) {
+	parser := proto.NewParser(strings.NewReader(`syntax = "proto2";
+enum Mode {
+  OFF = 0;
+  ON = 1;
+}
+message Test {
+  enum Level {
+    LOW = 0;
+    HIGH = 1;
+  }
+  // @wot:description Operating mode
+  optional Mode mode = 1 [default = ON];
+  repeated Level levels = 2;
+}`))
+	definition, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := generateDataSchemas(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode := b.ds["Test"].Properties["mode"]
+	if mode.DataType != "string" || !reflect.DeepEqual(mode.Enum, []interface{}{"OFF", "ON"}) {
+		t.Errorf("Expected the field mode to hold the names of the values of Mode, but got %v", mode)
+	}
+	if mode.Default != "ON" || mode.Description != "Operating mode" {
+		t.Errorf("Expected the annotations of the field mode to be kept, but got %v", mode)
+	}
+	levels := b.ds["Test"].Properties["levels"]
+	if levels.Items == nil || !reflect.DeepEqual(levels.Items.Enum, []interface{}{"LOW", "HIGH"}) {
+		t.Errorf("Expected the items of the field levels to hold the values of the nested enum Level, but got %v", levels)
+	}
+}
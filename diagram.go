@@ -0,0 +1,246 @@
+package grpcwot
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// messageGraph holds the messages and enums of a proto file as nodes and the fields referencing them as edges,
+// it is drawn as Mermaid diagram in the Markdown documentation and as SVG in the HTML documentation
+type messageGraph struct {
+	nodes []graphNode
+	edges []graphEdge
+}
+
+// graphNode is a message or an enum
+type graphNode struct {
+	name   string
+	isEnum bool
+}
+
+// graphEdge is a field of the message from referencing the message or enum to, labelled by the field
+type graphEdge struct {
+	from, to, label string
+}
+
+// messageGraph collects the messages and enums and their references, cf. lm. Referenced types which are not declared
+// in the proto file, e.g. imported messages, are added as nodes as well
+func (b *dataSchemaBuilder) messageGraph() messageGraph {
+	var g messageGraph
+	declared := map[string]bool{}
+	for _, k := range b.messageNames() {
+		g.nodes = append(g.nodes, graphNode{name: k, isEnum: len(b.ds[k].Enum) != 0})
+		declared[k] = true
+	}
+	for _, v := range b.lm {
+		label := v.n
+		if v.o != "" {
+			label = v.o + "." + v.n
+		} else if p := b.ds[v.pm].Properties[v.n]; p.DataType == "array" {
+			label += "[]"
+		}
+		g.edges = append(g.edges, graphEdge{from: v.pm, to: v.t, label: label})
+		for _, n := range []string{v.pm, v.t} {
+			if !declared[n] {
+				g.nodes = append(g.nodes, graphNode{name: n})
+				declared[n] = true
+			}
+		}
+	}
+	return g
+}
+
+// diagramID returns the id of a node in the Mermaid diagram. Underscores are doubled and dots are replaced by _d, so
+// the ids of different names differ, e.g. a.b_c results in a_db__c and a_b.c in a__b_dc
+func diagramID(name string) string {
+	return strings.NewReplacer("_", "__", ".", "_d").Replace(name)
+}
+
+// mermaid draws the graph as Mermaid flowchart, enums are drawn as rounded nodes
+func (g messageGraph) mermaid() string {
+	var s strings.Builder
+	s.WriteString("flowchart LR\n")
+	for _, n := range g.nodes {
+		if n.isEnum {
+			fmt.Fprintf(&s, "  %s([\"%s\"])\n", diagramID(n.name), n.name)
+		} else {
+			fmt.Fprintf(&s, "  %s[\"%s\"]\n", diagramID(n.name), n.name)
+		}
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&s, "  %s -->|\"%s\"| %s\n", diagramID(e.from), e.label, diagramID(e.to))
+	}
+	return s.String()
+}
+
+// Dimensions of the SVG diagram in pixels, the width of the text is estimated from the number of characters
+const (
+	svgCharWidth   = 7
+	svgNodePadding = 12
+	svgNodeHeight  = 30
+	svgRowGap      = 24
+	svgColumnGap   = 110
+	svgMargin      = 20
+)
+
+// layers assigns the nodes to columns from left to right, so each message is left of the types of its fields. The
+// edges closing a cycle, e.g. of recursive messages, are ignored. The nodes of a column are ordered by the mean row
+// of the nodes referencing them, which reduces the crossings of the edges
+func (g messageGraph) layers() [][]string {
+	index := map[string]int{}
+	for k, n := range g.nodes {
+		index[n.name] = k
+	}
+	next := make([][]int, len(g.nodes))
+	for _, e := range g.edges {
+		next[index[e.from]] = append(next[index[e.from]], index[e.to])
+	}
+	// depth first search in the order of the nodes, the edges to a node on the stack close a cycle
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make([]int, len(g.nodes))
+	layer := make([]int, len(g.nodes))
+	var order []int
+	var visit func(k int)
+	visit = func(k int) {
+		state[k] = onStack
+		for _, t := range next[k] {
+			if state[t] == unvisited {
+				visit(t)
+			}
+		}
+		state[k] = done
+		order = append(order, k)
+	}
+	for k := range g.nodes {
+		if state[k] == unvisited {
+			visit(k)
+		}
+	}
+	// the reverse post order is a topological order of the graph without the edges closing a cycle
+	position := make([]int, len(g.nodes))
+	for k, n := range order {
+		position[n] = len(order) - 1 - k
+	}
+	for k := len(order) - 1; k >= 0; k-- {
+		n := order[k]
+		for _, t := range next[n] {
+			if position[t] > position[n] && layer[t] < layer[n]+1 {
+				layer[t] = layer[n] + 1
+			}
+		}
+	}
+	var columns [][]string
+	row := map[string]float64{}
+	for k, n := range g.nodes {
+		for len(columns) <= layer[k] {
+			columns = append(columns, nil)
+		}
+		columns[layer[k]] = append(columns[layer[k]], n.name)
+	}
+	for c, column := range columns {
+		mean := map[string]float64{}
+		for _, n := range column {
+			sum, count := 0.0, 0
+			for _, e := range g.edges {
+				if r, ok := row[e.from]; ok && e.to == n && e.from != n {
+					sum, count = sum+r, count+1
+				}
+			}
+			mean[n] = float64(len(g.nodes))
+			if count != 0 {
+				mean[n] = sum / float64(count)
+			}
+		}
+		if c != 0 {
+			sort.SliceStable(column, func(i, j int) bool { return mean[column[i]] < mean[column[j]] })
+		}
+		for r, n := range column {
+			row[n] = float64(r)
+		}
+	}
+	return columns
+}
+
+// svgBox is the position of a node in the SVG diagram
+type svgBox struct {
+	x, y, width int
+}
+
+// svg draws the graph as inline SVG, so the HTML documentation shows the diagram without loading a renderer. The
+// columns of the nodes follow the references from left to right like the Mermaid flowchart
+func (g messageGraph) svg() template.HTML {
+	if len(g.nodes) == 0 {
+		return ""
+	}
+	enums := map[string]bool{}
+	for _, n := range g.nodes {
+		enums[n.name] = n.isEnum
+	}
+	boxes := map[string]svgBox{}
+	x, height := svgMargin, 0
+	for _, column := range g.layers() {
+		width := 0
+		for _, n := range column {
+			if w := len(n)*svgCharWidth + 2*svgNodePadding; w > width {
+				width = w
+			}
+		}
+		for r, n := range column {
+			boxes[n] = svgBox{x: x, y: svgMargin + r*(svgNodeHeight+svgRowGap), width: width}
+		}
+		if h := svgMargin + len(column)*(svgNodeHeight+svgRowGap); h > height {
+			height = h
+		}
+		x += width + svgColumnGap
+	}
+	width := x - svgColumnGap + svgMargin
+
+	var s strings.Builder
+	fmt.Fprintf(&s, `<svg xmlns="http://www.w3.org/2000/svg" class="diagram" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	s.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#555"/></marker></defs>` + "\n")
+	for _, e := range g.edges {
+		from, to := boxes[e.from], boxes[e.to]
+		x1, y1 := from.x+from.width, from.y+svgNodeHeight/2
+		x2, y2 := to.x, to.y+svgNodeHeight/2
+		var path string
+		var lx, ly int
+		if e.from == e.to {
+			// a recursive message references itself by a loop above the node
+			x1, x2 = from.x+from.width*2/3, from.x+from.width/3
+			y1, y2 = from.y, from.y
+			path = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, x1, y1-svgRowGap, x2, y2-svgRowGap, x2, y2)
+			lx, ly = (x1+x2)/2, y1-svgRowGap*3/4
+		} else {
+			dx := svgColumnGap / 2
+			if x2 < x1 {
+				// references to the left bend around the nodes
+				dx = svgColumnGap
+			}
+			path = fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, x1+dx, y1, x2-dx, y2, x2, y2)
+			lx, ly = (x1+x2)/2, (y1+y2)/2-4
+		}
+		fmt.Fprintf(&s, `<path d="%s" fill="none" stroke="#555" marker-end="url(#arrow)"/>`+"\n", path)
+		fmt.Fprintf(&s, `<text x="%d" y="%d" text-anchor="middle" font-size="11" fill="#333" stroke="#fff" stroke-width="3" paint-order="stroke">%s</text>`+"\n",
+			lx, ly, template.HTMLEscapeString(e.label))
+	}
+	for _, n := range g.nodes {
+		b := boxes[n.name]
+		radius := 4
+		if enums[n.name] {
+			radius = svgNodeHeight / 2
+		}
+		fmt.Fprintf(&s, `<g><title>%s</title><rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="#eef3fb" stroke="#4a6fa5"/>`,
+			template.HTMLEscapeString(n.name), b.x, b.y, b.width, svgNodeHeight, radius)
+		fmt.Fprintf(&s, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="central">%s</text></g>`+"\n",
+			b.x+b.width/2, b.y+svgNodeHeight/2, template.HTMLEscapeString(n.name))
+	}
+	s.WriteString("</svg>")
+	return template.HTML(s.String())
+}
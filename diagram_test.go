@@ -0,0 +1,48 @@
+package grpcwot
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDiagramID(t *testing.T) {
+	if a, b := diagramID("a.b_c"), diagramID("a_b.c"); a == b {
+		t.Errorf("Expected different ids of a.b_c and a_b.c, but got %s", a)
+	}
+	if id := diagramID("Program.Kind"); id != "Program_dKind" {
+		t.Errorf("Expected the id Program_dKind, but got %s", id)
+	}
+}
+
+func TestMessageGraphLayers(t *testing.T) {
+	g := messageGraph{
+		nodes: []graphNode{{name: "Drum"}, {name: "Node"}, {name: "Program"}, {name: "Program.Kind", isEnum: true}},
+		edges: []graphEdge{
+			{"Program", "Program.Kind", "kind"},
+			{"Drum", "Program", "program"},
+			{"Node", "Node", "children[]"},
+		},
+	}
+	layers := g.layers()
+	if len(layers) != 3 || strings.Join(layers[0], ",") != "Drum,Node" || layers[1][0] != "Program" ||
+		layers[2][0] != "Program.Kind" {
+		t.Errorf("Expected the columns [Drum Node] [Program] [Program.Kind], but got %v", layers)
+	}
+
+	// a cycle does not prevent the layout
+	g.edges = append(g.edges, graphEdge{"Program.Kind", "Drum", "drum"})
+	if layers := g.layers(); len(layers) != 3 {
+		t.Errorf("Expected the edge closing the cycle to be ignored, but got %v", layers)
+	}
+
+	svg := string(g.svg())
+	if err := xml.Unmarshal([]byte(svg), new(struct{})); err != nil {
+		t.Errorf("Expected well-formed SVG, but got %v\n%s", err, svg)
+	}
+	for _, want := range []string{">Program.Kind</text>", ">children[]</text>", `rx="15"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("Expected the SVG to contain %q, but got\n%s", want, svg)
+		}
+	}
+}
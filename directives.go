@@ -0,0 +1,142 @@
+package grpcwot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// directivePrefix introduces a structured comment directive, e.g. // @wot:property name=Mode readOnly
+const directivePrefix = "@wot:"
+
+// directive is a structured comment directive attached to a RPC or a message field
+type directive struct {
+	name  string            // name of the directive, e.g. property, event or unit
+	value string            // the remaining text after the name
+	args  map[string]string // key=value arguments and flags (with value "true") of the directive
+}
+
+// splitDirectiveArgs splits the arguments of a directive at whitespaces, while keeping double quoted values together
+func splitDirectiveArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if current.Len() != 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() != 0 {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// parseDirectives reads all directives in the comments
+func parseDirectives(comments ...*proto.Comment) []directive {
+	var ds []directive
+	for _, c := range comments {
+		if c == nil {
+			continue
+		}
+		for _, l := range c.Lines {
+			l = strings.TrimSpace(l)
+			if !strings.HasPrefix(l, directivePrefix) {
+				continue
+			}
+			l = strings.TrimPrefix(l, directivePrefix)
+			d := directive{args: map[string]string{}}
+			parts := strings.SplitN(l, " ", 2)
+			d.name = parts[0]
+			if len(parts) == 2 {
+				d.value = strings.TrimSpace(parts[1])
+			}
+			for _, a := range splitDirectiveArgs(d.value) {
+				kv := strings.SplitN(a, "=", 2)
+				if len(kv) == 2 {
+					d.args[kv[0]] = kv[1]
+				} else {
+					d.args[kv[0]] = "true"
+				}
+			}
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// affordanceDirective reads the classification of a RPC from a @wot:property, @wot:action or @wot:event directive
+func affordanceDirective(r *proto.RPC) (wotAffordance, bool) {
+	if r == nil {
+		return wotAffordance{}, false
+	}
+	for _, d := range parseDirectives(r.Comment, r.InlineComment) {
+		a := wotAffordance{}
+		switch d.name {
+		case "property":
+			a.kind = "PROPERTY"
+		case "action":
+			a.kind = "ACTION"
+		case "event":
+			a.kind = "EVENT"
+		default:
+			continue
+		}
+		a.name = d.args["name"]
+		a.title = d.args["title"]
+		a.description = d.args["description"]
+		a.observable = d.args["observable"] == "true"
+		a.readOnly = d.args["readOnly"] == "true"
+		if s := d.args["security"]; s != "" {
+			a.security = strings.Split(s, ",")
+		}
+		if e := d.args["errors"]; e != "" {
+			a.errors = strings.Split(e, ",")
+		}
+		return a, true
+	}
+	return wotAffordance{}, false
+}
+
+// applyFieldDirectives refines the DataSchema of a field according to its comment directives
+func applyFieldDirectives(ds *wot.DataSchema, f *proto.Field) {
+	for _, d := range parseDirectives(f.Comment, f.InlineComment) {
+		switch d.name {
+		case "unit":
+			ds.Unit = d.value
+		case "title":
+			ds.Title = d.value
+		case "description":
+			ds.Description = d.value
+		case "format":
+			ds.Format = d.value
+		case "readOnly":
+			ds.ReadOnly = true
+		case "writeOnly":
+			ds.WriteOnly = true
+		case "minimum", "maximum":
+			f, err := strconv.ParseFloat(d.value, 64)
+			if err != nil {
+				continue
+			}
+			if ds.NumberSchema == nil {
+				ds.NumberSchema = &wot.NumberSchema{}
+			}
+			if d.name == "minimum" {
+				ds.Minimum = &f
+			} else {
+				ds.Maximum = &f
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package grpcwot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+var parseDirectivesTest = []struct {
+	in  *proto.Comment
+	out []directive
+}{
+	{
+		&proto.Comment{Lines: []string{" Returns the mode", " @wot:property name=Mode readOnly"}},
+		[]directive{
+			{name: "property", value: "name=Mode readOnly", args: map[string]string{"name": "Mode", "readOnly": "true"}},
+		},
+	},
+	{
+		&proto.Comment{Lines: []string{` @wot:event title="Temperature changes"`}},
+		[]directive{
+			{name: "event", value: `title="Temperature changes"`, args: map[string]string{"title": "Temperature changes"}},
+		},
+	},
+	{
+		&proto.Comment{Lines: []string{" @wot:unit celsius", " @wot:readOnly"}},
+		[]directive{
+			{name: "unit", value: "celsius", args: map[string]string{"celsius": "true"}},
+			{name: "readOnly", args: map[string]string{}},
+		},
+	},
+	{
+		&proto.Comment{Lines: []string{" no directive @wot:event"}},
+		nil,
+	},
+	{
+		nil,
+		nil,
+	},
+}
+
+func TestParseDirectives(t *testing.T) {
+	for _, tt := range parseDirectivesTest {
+		result := parseDirectives(tt.in)
+		if !reflect.DeepEqual(result, tt.out) {
+			t.Errorf("parseDirectives(%v) => \n%v, want \n%v", tt.in, result, tt.out)
+		}
+	}
+}
+
+var affordanceAnnotationTest = []struct {
+	in  *proto.RPC
+	out wotAffordance
+	ok  bool
+}{
+	{
+		&proto.RPC{Name: "GetMode", Comment: &proto.Comment{Lines: []string{" @wot:property name=Mode readOnly"}}},
+		wotAffordance{kind: "PROPERTY", name: "Mode", readOnly: true},
+		true,
+	},
+	{
+		&proto.RPC{Name: "Alarm", InlineComment: &proto.Comment{Lines: []string{" @wot:event"}}},
+		wotAffordance{kind: "EVENT"},
+		true,
+	},
+	{
+		// the option takes precedence over the directive
+		&proto.RPC{
+			Name:    "GetMode",
+			Comment: &proto.Comment{Lines: []string{" @wot:action title=Mode"}},
+			Elements: []proto.Visitee{
+				aggregateOption("(wot.affordance)", map[string]string{"kind": "PROPERTY", "observable": "true"}),
+			},
+		},
+		wotAffordance{kind: "PROPERTY", title: "Mode", observable: true},
+		true,
+	},
+	{
+		&proto.RPC{Name: "Reset", Comment: &proto.Comment{Lines: []string{" @wot:action security=bearer_sc,apikey_sc"}}},
+		wotAffordance{kind: "ACTION", security: []string{"bearer_sc", "apikey_sc"}},
+		true,
+	},
+	{
+		&proto.RPC{Name: "GetMode", Comment: &proto.Comment{Lines: []string{" Returns the mode"}}},
+		wotAffordance{},
+		false,
+	},
+}
+
+func TestAffordanceAnnotation(t *testing.T) {
+	for _, tt := range affordanceAnnotationTest {
+		result, ok := affordanceAnnotation(tt.in)
+		if ok != tt.ok || !reflect.DeepEqual(result, tt.out) {
+			t.Errorf("affordanceAnnotation(%v) => %v, %v, want %v, %v", tt.in.Name, result, ok, tt.out, tt.ok)
+		}
+	}
+}
+
+func TestApplyFieldDirectives(t *testing.T) {
+	ds := wot.DataSchema{DataType: "number"}
+	f := &proto.Field{
+		Name:    "temperature",
+		Type:    "double",
+		Comment: &proto.Comment{Lines: []string{" @wot:unit celsius", " @wot:maximum 85"}},
+		Options: []*proto.Option{
+			aggregateOption("(wot.field)", map[string]string{"unit": "om:degreeCelsius"}),
+		},
+	}
+	applyFieldDirectives(&ds, f)
+	if ds.Unit != "celsius" {
+		t.Errorf("Expected the unit celsius, but got %v", ds.Unit)
+	}
+	if ds.NumberSchema == nil || ds.Maximum == nil || *ds.Maximum != 85.0 {
+		t.Errorf("Expected the maximum 85, but got %v", ds.NumberSchema)
+	}
+	applyFieldOption(&ds, f)
+	if ds.Unit != "om:degreeCelsius" {
+		t.Errorf("Expected the option to override the unit, but got %v", ds.Unit)
+	}
+}
@@ -0,0 +1,412 @@
+package grpcwot
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// Formats of the documentation of a Thing
+const (
+	DocsMarkdown = "markdown"
+	DocsHTML     = "html"
+)
+
+// thingDocs is the documentation of a Thing, which is rendered as Markdown or HTML
+type thingDocs struct {
+	Title       string
+	Description string
+	Overview    [][2]string
+	Sections    []affordanceSection
+	Diagram     string        // Mermaid source of the diagram of the messages
+	DiagramSVG  template.HTML // the diagram drawn as SVG for the HTML documentation
+}
+
+// affordanceSection documents the affordances of one type
+type affordanceSection struct {
+	Title       string
+	Affordances []affordanceDocs
+}
+
+// affordanceDocs documents an affordance with its operations and the schemas of its data
+type affordanceDocs struct {
+	Name        string
+	Title       string
+	Description string
+	Operations  []operationDocs
+	Schemas     []namedSchema
+}
+
+// operationDocs is a row of the table of an affordance type
+type operationDocs struct {
+	Op   string
+	Href string
+	RPC  string
+}
+
+// namedSchema is a schema tree labelled by its role, e.g. Input
+type namedSchema struct {
+	Label string
+	Root  schemaNode
+}
+
+// schemaNode is a data schema in the schema tree, its children are the properties, items or alternatives
+type schemaNode struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+	Constraints []string
+	Children    []schemaNode
+}
+
+// GenerateDocs renders the TD of the proto file as Markdown or self-contained HTML document: an overview of the Thing,
+// a table per affordance type with the operations, target IRIs and RPCs, the schema trees of the affordances and a
+// Mermaid diagram of the references between the messages
+func GenerateDocs(protoFile, classConfigFile, format string, opts ...Option) (string, error) {
+	b, err := generate(protoFile, classConfigFile, "127.0.0.1", 50051, true, opts...)
+	if err != nil {
+		return "", err
+	}
+	d := b.docs()
+	switch format {
+	case DocsMarkdown:
+		return d.markdown(), nil
+	case DocsHTML:
+		return d.html()
+	}
+	return "", fmt.Errorf("unknown format %s, expected %s or %s", format, DocsMarkdown, DocsHTML)
+}
+
+// docs collects the documentation of the TD
+func (b *builder) docs() thingDocs {
+	d := thingDocs{Title: b.td.Title, Description: b.td.Description}
+	if len(b.bindings) != 0 {
+		d.Overview = append(d.Overview, [2]string{"Service", b.bindings[0].Service})
+	}
+	if b.td.Version != nil && b.td.Version.Instance != "" {
+		d.Overview = append(d.Overview, [2]string{"Version", b.td.Version.Instance})
+	}
+	if b.td.ID != "" {
+		d.Overview = append(d.Overview, [2]string{"ID", b.td.ID})
+	}
+	d.Overview = append(d.Overview, [2]string{"Base", b.td.Base})
+	var security []string
+	names, _ := b.td.Security.([]string)
+	if n, ok := b.td.Security.(string); ok {
+		names = []string{n}
+	}
+	for _, n := range names {
+		security = append(security, n+" ("+b.td.SecurityDefinitions[n].Scheme+")")
+	}
+	d.Overview = append(d.Overview, [2]string{"Security", strings.Join(security, ", ")})
+
+	properties := affordanceSection{Title: "Properties"}
+	for _, n := range b.td.PropertyNames() {
+		p := b.td.Properties[n]
+		a := b.affordanceDocs(n, p.InteractionAffordance)
+		a.Schemas = []namedSchema{{"Value", newSchemaNode("", p.DataSchema)}}
+		properties.Affordances = append(properties.Affordances, a)
+	}
+	actions := affordanceSection{Title: "Actions"}
+	for _, n := range b.td.ActionNames() {
+		v := b.td.Actions[n]
+		a := b.affordanceDocs(n, v.InteractionAffordance)
+		if v.Input != nil {
+			a.Schemas = append(a.Schemas, namedSchema{"Input", newSchemaNode("", *v.Input)})
+		}
+		if v.Output != nil {
+			a.Schemas = append(a.Schemas, namedSchema{"Output", newSchemaNode("", *v.Output)})
+		}
+		actions.Affordances = append(actions.Affordances, a)
+	}
+	events := affordanceSection{Title: "Events"}
+	for _, n := range b.td.EventNames() {
+		v := b.td.Events[n]
+		a := b.affordanceDocs(n, v.InteractionAffordance)
+		if v.Data != nil {
+			a.Schemas = append(a.Schemas, namedSchema{"Data", newSchemaNode("", *v.Data)})
+		}
+		events.Affordances = append(events.Affordances, a)
+	}
+	for _, s := range []affordanceSection{properties, actions, events} {
+		if len(s.Affordances) != 0 {
+			d.Sections = append(d.Sections, s)
+		}
+	}
+	graph := b.dsb.messageGraph()
+	d.Diagram, d.DiagramSVG = graph.mermaid(), graph.svg()
+	return d
+}
+
+// affordanceDocs documents the operations of the affordance named n, which are bound to RPCs
+func (b *builder) affordanceDocs(n string, ia wot.InteractionAffordance) affordanceDocs {
+	a := affordanceDocs{Name: n, Title: ia.Title, Description: ia.Description}
+	href := b.GetIRI(n)
+	for _, v := range b.bindings {
+		if v.Href != href {
+			continue
+		}
+		o := operationDocs{Op: v.Op, Href: v.Href, RPC: v.RPC}
+		// the target IRI of the gRPC form holds the URI template of the URI variables
+		for _, f := range ia.Forms {
+			if f.ContentType == contentTypeGrpc && contains(f.Ops(), v.Op) {
+				o.Href = f.Href
+				break
+			}
+		}
+		a.Operations = append(a.Operations, o)
+	}
+	return a
+}
+
+// newSchemaNode builds the schema tree of the data schema
+func newSchemaNode(name string, ds wot.DataSchema) schemaNode {
+	n := schemaNode{Name: name, Type: ds.DataType, Description: ds.Description, Constraints: constraints(ds)}
+	if n.Description == "" && ds.Title != name {
+		n.Description = ds.Title
+	}
+	switch {
+	case len(ds.OneOf) != 0:
+		n.Type = "oneOf"
+		for _, v := range ds.OneOf {
+			n.Children = append(n.Children, newSchemaNode(v.Title, v))
+		}
+	case ds.ObjectSchema != nil:
+		for _, k := range ds.ObjectSchema.PropertyNames() {
+			c := newSchemaNode(k, ds.Properties[k])
+			c.Required = contains(ds.Required, k)
+			n.Children = append(n.Children, c)
+		}
+	case ds.ArraySchema != nil && ds.Items != nil:
+		n.Children = append(n.Children, newSchemaNode("items", *ds.Items))
+	}
+	return n
+}
+
+// constraints describes the terms of the data schema which restrict its values
+func constraints(ds wot.DataSchema) []string {
+	var c []string
+	add := func(term string, v interface{}) {
+		c = append(c, fmt.Sprintf("%s: %v", term, v))
+	}
+	if ds.Const != nil {
+		add("const", ds.Const)
+	}
+	if ds.Default != nil {
+		add("default", ds.Default)
+	}
+	if len(ds.Enum) != 0 {
+		var values []string
+		for _, v := range ds.Enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		add("enum", strings.Join(values, ", "))
+	}
+	if ds.Unit != "" {
+		add("unit", ds.Unit)
+	}
+	if ds.Format != "" {
+		add("format", ds.Format)
+	}
+	if ds.NumberSchema != nil {
+		for _, v := range []struct {
+			term  string
+			value *float64
+		}{
+			{"minimum", ds.Minimum},
+			{"exclusiveMinimum", ds.ExclusiveMinimum},
+			{"maximum", ds.Maximum},
+			{"exclusiveMaximum", ds.ExclusiveMaximum},
+			{"multipleOf", ds.MultipleOf},
+		} {
+			if v.value != nil {
+				add(v.term, strconv.FormatFloat(*v.value, 'g', -1, 64))
+			}
+		}
+	}
+	if ds.StringSchema != nil {
+		if ds.MinLength != nil {
+			add("minLength", *ds.MinLength)
+		}
+		if ds.MaxLength != nil {
+			add("maxLength", *ds.MaxLength)
+		}
+		if ds.Pattern != "" {
+			add("pattern", ds.Pattern)
+		}
+		if ds.ContentEncoding != "" {
+			add("contentEncoding", ds.ContentEncoding)
+		}
+		if ds.ContentMediaType != "" {
+			add("contentMediaType", ds.ContentMediaType)
+		}
+	}
+	if ds.ArraySchema != nil {
+		if ds.MinItems != nil {
+			add("minItems", *ds.MinItems)
+		}
+		if ds.MaxItems != nil {
+			add("maxItems", *ds.MaxItems)
+		}
+	}
+	if ds.ReadOnly {
+		c = append(c, "readOnly")
+	}
+	if ds.WriteOnly {
+		c = append(c, "writeOnly")
+	}
+	return c
+}
+
+// markdown renders the documentation as Markdown, the schema trees are collapsed in details elements
+func (d thingDocs) markdown() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "# %s\n", d.Title)
+	if d.Description != "" {
+		fmt.Fprintf(&s, "\n%s\n", d.Description)
+	}
+	s.WriteString("\n| | |\n|---|---|\n")
+	for _, v := range d.Overview {
+		fmt.Fprintf(&s, "| %s | %s |\n", v[0], markdownCell(v[1]))
+	}
+	for _, section := range d.Sections {
+		fmt.Fprintf(&s, "\n## %s\n\n", section.Title)
+		s.WriteString("| Name | Operation | Href | RPC |\n|---|---|---|---|\n")
+		for _, a := range section.Affordances {
+			for k, o := range a.Operations {
+				name := ""
+				if k == 0 {
+					name = fmt.Sprintf("[%s](#%s)", markdownCell(a.Name), anchor(a.Name))
+				}
+				fmt.Fprintf(&s, "| %s | %s | `%s` | `%s` |\n", name, o.Op, markdownCell(o.Href), o.RPC)
+			}
+		}
+		for _, a := range section.Affordances {
+			fmt.Fprintf(&s, "\n### %s\n", a.Name)
+			if a.Title != "" && a.Title != a.Name {
+				fmt.Fprintf(&s, "\n**%s**\n", a.Title)
+			}
+			if a.Description != "" {
+				fmt.Fprintf(&s, "\n%s\n", a.Description)
+			}
+			for _, schema := range a.Schemas {
+				fmt.Fprintf(&s, "\n<details>\n<summary>%s</summary>\n\n", schema.Label)
+				writeMarkdownSchema(&s, schema.Root, 0)
+				s.WriteString("\n</details>\n")
+			}
+		}
+	}
+	if d.Diagram != "" {
+		fmt.Fprintf(&s, "\n## Messages\n\n```mermaid\n%s```\n", d.Diagram)
+	}
+	return s.String()
+}
+
+// writeMarkdownSchema writes the schema tree as nested list
+func writeMarkdownSchema(s *strings.Builder, n schemaNode, depth int) {
+	s.WriteString(strings.Repeat("  ", depth) + "- ")
+	if n.Name != "" {
+		fmt.Fprintf(s, "**%s** ", n.Name)
+	}
+	fmt.Fprintf(s, "`%s`", n.Type)
+	if n.Required {
+		s.WriteString(" *required*")
+	}
+	if n.Description != "" {
+		s.WriteString(" — " + n.Description)
+	}
+	if len(n.Constraints) != 0 {
+		s.WriteString(" (" + strings.Join(n.Constraints, "; ") + ")")
+	}
+	s.WriteString("\n")
+	for _, c := range n.Children {
+		writeMarkdownSchema(s, c, depth+1)
+	}
+}
+
+// markdownCell escapes the pipes in the content of a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// anchor returns the anchor of a heading as generated by GitHub
+func anchor(heading string) string {
+	var s strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			s.WriteRune('-')
+		case r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			s.WriteRune(r)
+		}
+	}
+	return s.String()
+}
+
+// docsTemplate renders the documentation as self-contained HTML document. The diagram of the messages is embedded as
+// SVG, its Mermaid source is given for reuse
+var docsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{"anchor": anchor}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+code { background: #f4f4f4; padding: 0 0.2em; }
+details { margin: 0.5em 0; }
+summary { cursor: pointer; font-weight: bold; }
+ul.schema { list-style: none; padding-left: 1.2em; border-left: 1px solid #ddd; }
+.required { color: #b00; font-size: 0.9em; }
+.constraints { color: #555; font-size: 0.9em; }
+pre.mermaid { background: #f8f8f8; padding: 1em; overflow-x: auto; }
+div.diagram { overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Description}}<p>{{.}}</p>
+{{end}}<table>
+{{range .Overview}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{range .Sections}}<h2>{{.Title}}</h2>
+<table>
+<tr><th>Name</th><th>Operation</th><th>Href</th><th>RPC</th></tr>
+{{range .Affordances}}{{$a := .}}{{range $k, $o := .Operations}}<tr><td>{{if eq $k 0}}<a href="#{{anchor $a.Name}}">{{$a.Name}}</a>{{end}}</td><td>{{$o.Op}}</td><td><code>{{$o.Href}}</code></td><td><code>{{$o.RPC}}</code></td></tr>
+{{end}}{{end}}</table>
+{{range .Affordances}}<h3 id="{{anchor .Name}}">{{.Name}}</h3>
+{{if and .Title (ne .Title .Name)}}<p><strong>{{.Title}}</strong></p>
+{{end}}{{with .Description}}<p>{{.}}</p>
+{{end}}{{range .Schemas}}<details>
+<summary>{{.Label}}</summary>
+<ul class="schema">{{template "schema" .Root}}</ul>
+</details>
+{{end}}{{end}}{{end}}{{with .DiagramSVG}}<h2>Messages</h2>
+<div class="diagram">
+{{.}}
+</div>
+<details>
+<summary>Mermaid source</summary>
+<pre class="mermaid">
+{{$.Diagram}}</pre>
+</details>
+{{end}}</body>
+</html>
+{{define "schema"}}<li>{{with .Name}}<strong>{{.}}</strong> {{end}}<code>{{.Type}}</code>{{if .Required}} <span class="required">required</span>{{end}}{{with .Description}} — {{.}}{{end}}{{with .Constraints}} <span class="constraints">({{range $k, $c := .}}{{if $k}}; {{end}}{{$c}}{{end}})</span>{{end}}{{with .Children}}
+<ul class="schema">{{range .}}{{template "schema" .}}{{end}}</ul>{{end}}</li>
+{{end}}`))
+
+// html renders the documentation as HTML document
+func (d thingDocs) html() (string, error) {
+	var buf bytes.Buffer
+	if err := docsTemplate.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
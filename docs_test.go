@@ -0,0 +1,86 @@
+package grpcwot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	md, err := GenerateDocs("cmd/prototd/test/enums/input.proto", "", DocsMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Washer\n",
+		"| Service | acme.washer.v1.Washer |\n",
+		"| [Drum](#drum) | readproperty | `Washer/Drum{?drum}` | `GetDrum` |\n",
+		"|  | writeproperty | `Washer/Program` | `SetProgram` |\n",
+		"  - **load** `number` — Load of the drum (unit: om:kilogram)\n",
+		"      - **preset** `string` *required*\n",
+		"```mermaid\nflowchart LR\n",
+		"  Program_dKind([\"Program.Kind\"])\n",
+		"  Drum -->|\"history[]\"| Temperature\n",
+		"  StartRequest -->|\"selection.program\"| Program\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected the Markdown documentation to contain %q, but got\n%s", want, md)
+		}
+	}
+
+	html, err := GenerateDocs("cmd/prototd/test/validation-rules/input.proto", "", DocsHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h3 id=\"volume\">Volume</h3>",
+		"<summary>Value</summary>",
+		"<li><strong>level</strong> <code>integer</code> <span class=\"constraints\">(minimum: 0; maximum: 100)</span></li>",
+		"pattern: ^[a-z]&#43;$",
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" class=\"diagram\"",
+		"<summary>Mermaid source</summary>\n<pre class=\"mermaid\">\nflowchart LR\n",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected the HTML documentation to contain %q, but got\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "src=") || strings.Contains(html, "<link") {
+		t.Errorf("Expected a self-contained HTML document without external resources")
+	}
+
+	if _, err := GenerateDocs("cmd/prototd/test/enums/input.proto", "", "pdf"); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestSchemaNode(t *testing.T) {
+	min := 1
+	n := newSchemaNode("", wot.DataSchema{
+		DataType: "object",
+		ObjectSchema: &wot.ObjectSchema{
+			Properties: map[string]wot.DataSchema{
+				"name": {DataType: "string", Title: "Name", StringSchema: &wot.StringSchema{MinLength: &min}},
+				"id":   {DataType: "integer", ReadOnly: true},
+			},
+			Required: []string{"name"},
+		},
+	})
+	if len(n.Children) != 2 || n.Children[0].Name != "id" || n.Children[1].Name != "name" {
+		t.Fatalf("Expected the properties sorted by name, but got %v", n.Children)
+	}
+	if c := n.Children[0]; c.Required || strings.Join(c.Constraints, ";") != "readOnly" {
+		t.Errorf("Expected the optional read-only id, but got %v", c)
+	}
+	if c := n.Children[1]; !c.Required || c.Description != "Name" || strings.Join(c.Constraints, ";") != "minLength: 1" {
+		t.Errorf("Expected the required name with its title and minLength, but got %v", c)
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	for in, out := range map[string]string{"Brightness": "brightness", "Room Level-2": "room-level-2", "a.b_c": "ab_c"} {
+		if got := anchor(in); got != out {
+			t.Errorf("anchor(%q) = %q, want %q", in, got, out)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package grpcwot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// grpcStatusCodes are the canonical error codes of gRPC
+// cf. https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+var grpcStatusCodes = map[string]int{
+	"CANCELLED":           1,
+	"UNKNOWN":             2,
+	"INVALID_ARGUMENT":    3,
+	"DEADLINE_EXCEEDED":   4,
+	"NOT_FOUND":           5,
+	"ALREADY_EXISTS":      6,
+	"PERMISSION_DENIED":   7,
+	"RESOURCE_EXHAUSTED":  8,
+	"FAILED_PRECONDITION": 9,
+	"ABORTED":             10,
+	"OUT_OF_RANGE":        11,
+	"UNIMPLEMENTED":       12,
+	"INTERNAL":            13,
+	"UNAVAILABLE":         14,
+	"DATA_LOSS":           15,
+	"UNAUTHENTICATED":     16,
+}
+
+// errorDetailsPackage is the package of the standard error details
+// cf. https://github.com/googleapis/googleapis/blob/master/google/rpc/error_details.proto
+const errorDetailsPackage = "google.rpc"
+
+// errorDetailSchemas describes the messages of google/rpc/error_details.proto, which are imported by services
+// rather than declared in their proto file
+var errorDetailSchemas = map[string]wot.DataSchema{
+	"ErrorInfo": objectDataSchema(map[string]wot.DataSchema{
+		"reason":   {DataType: "string"},
+		"domain":   {DataType: "string"},
+		"metadata": {DataType: "object"},
+	}),
+	"RetryInfo": objectDataSchema(map[string]wot.DataSchema{
+		"retry_delay": {DataType: "string"},
+	}),
+	"DebugInfo": objectDataSchema(map[string]wot.DataSchema{
+		"stack_entries": arrayDataSchema(wot.DataSchema{DataType: "string"}),
+		"detail":        {DataType: "string"},
+	}),
+	"QuotaFailure": objectDataSchema(map[string]wot.DataSchema{
+		"violations": arrayDataSchema(objectDataSchema(map[string]wot.DataSchema{
+			"subject":     {DataType: "string"},
+			"description": {DataType: "string"},
+		})),
+	}),
+	"PreconditionFailure": objectDataSchema(map[string]wot.DataSchema{
+		"violations": arrayDataSchema(objectDataSchema(map[string]wot.DataSchema{
+			"type":        {DataType: "string"},
+			"subject":     {DataType: "string"},
+			"description": {DataType: "string"},
+		})),
+	}),
+	"BadRequest": objectDataSchema(map[string]wot.DataSchema{
+		"field_violations": arrayDataSchema(objectDataSchema(map[string]wot.DataSchema{
+			"field":       {DataType: "string"},
+			"description": {DataType: "string"},
+		})),
+	}),
+	"RequestInfo": objectDataSchema(map[string]wot.DataSchema{
+		"request_id":   {DataType: "string"},
+		"serving_data": {DataType: "string"},
+	}),
+	"ResourceInfo": objectDataSchema(map[string]wot.DataSchema{
+		"resource_type": {DataType: "string"},
+		"resource_name": {DataType: "string"},
+		"owner":         {DataType: "string"},
+		"description":   {DataType: "string"},
+	}),
+	"Help": objectDataSchema(map[string]wot.DataSchema{
+		"links": arrayDataSchema(objectDataSchema(map[string]wot.DataSchema{
+			"description": {DataType: "string"},
+			"url":         {DataType: "string"},
+		})),
+	}),
+	"LocalizedMessage": objectDataSchema(map[string]wot.DataSchema{
+		"locale":  {DataType: "string"},
+		"message": {DataType: "string"},
+	}),
+}
+
+// objectDataSchema describes an object with the given properties
+func objectDataSchema(properties map[string]wot.DataSchema) wot.DataSchema {
+	return wot.DataSchema{DataType: "object", ObjectSchema: &wot.ObjectSchema{Properties: properties}}
+}
+
+// arrayDataSchema describes an array of the given items
+func arrayDataSchema(items wot.DataSchema) wot.DataSchema {
+	return wot.DataSchema{DataType: "array", ArraySchema: &wot.ArraySchema{Items: &items}}
+}
+
+// errorDetail resolves the message of the details of an error and returns its DataSchema and fully qualified name.
+// Messages of the proto file are found by their name, optionally qualified with the package. Other names refer to
+// the standard error details, e.g. google.rpc.PreconditionFailure or PreconditionFailure if it is not declared in the
+// proto file
+func (b *builder) errorDetail(detail string) (*wot.DataSchema, string, bool) {
+	name := strings.TrimPrefix(detail, ".")
+	local := name
+	if b.pkg != "" {
+		local = strings.TrimPrefix(name, b.pkg+".")
+	}
+	if ds, ok := b.dsb.ds[local]; ok {
+		if b.pkg != "" {
+			return ds, b.pkg + "." + local, true
+		}
+		return ds, local, true
+	}
+	standard := strings.TrimPrefix(name, errorDetailsPackage+".")
+	if ds, ok := errorDetailSchemas[standard]; ok {
+		return &ds, errorDetailsPackage + "." + standard, true
+	}
+	return nil, "", false
+}
+
+// rpcErrors returns the error codes documented for the RPC, the classification config takes precedence over the
+// annotations in the proto file. An error is given as code, optionally followed by the message of the details,
+// e.g. FAILED_PRECONDITION:PreconditionFailure
+func (b *builder) rpcErrors(r affs) []string {
+	if c, ok := b.ac[r.Name]; ok && len(c.Errors) != 0 {
+		return c.Errors
+	}
+	a, _ := affordanceAnnotation(r.RPC)
+	return a.errors
+}
+
+// saveErrors records the error codes of the RPC in the affordance classification
+func (b *builder) saveErrors(r affs, errs []string) {
+	if len(errs) == 0 {
+		return
+	}
+	c := b.ac[r.Name]
+	c.Errors = errs
+	b.ac[r.Name] = c
+}
+
+// statusDataSchema describes the google.rpc.Status of an error with the given code,
+// the details hold messages of the detail DataSchema if it is set
+func statusDataSchema(name string, code int, detail *wot.DataSchema, detailType string) wot.DataSchema {
+	anySchema := wot.DataSchema{
+		DataType: "object",
+		ObjectSchema: &wot.ObjectSchema{
+			Properties: map[string]wot.DataSchema{"@type": {DataType: "string"}},
+			Required:   []string{"@type"},
+		},
+	}
+	if detail != nil && detail.ObjectSchema != nil {
+		anySchema.Properties = map[string]wot.DataSchema{
+			"@type": {DataType: "string", Const: "type.googleapis.com/" + detailType},
+		}
+		for k, v := range detail.Properties {
+			anySchema.Properties[k] = v
+		}
+		anySchema.Required = append(anySchema.Required, detail.Required...)
+	}
+	return wot.DataSchema{
+		Title:    name,
+		DataType: "object",
+		ObjectSchema: &wot.ObjectSchema{
+			Properties: map[string]wot.DataSchema{
+				"code":    {DataType: "integer", Const: code},
+				"message": {DataType: "string"},
+				"details": {DataType: "array", ArraySchema: &wot.ArraySchema{Items: &anySchema}},
+			},
+			Required: []string{"code"},
+		},
+	}
+}
+
+// additionalResponses describes the errors of an affordance, the schemas of the errors are added to the
+// schemaDefinitions of the TD
+func (b *builder) additionalResponses(errs []string) ([]wot.AdditionalExpectedResponse, error) {
+	var responses []wot.AdditionalExpectedResponse
+	for _, e := range errs {
+		name, detail := e, ""
+		if k := strings.Index(e, ":"); k != -1 {
+			name, detail = e[:k], e[k+1:]
+		}
+		code, ok := grpcStatusCodes[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a gRPC status code", name)
+		}
+		var detailSchema *wot.DataSchema
+		var detailType string
+		if detail != "" {
+			if detailSchema, detailType, ok = b.errorDetail(detail); !ok {
+				return nil, fmt.Errorf("no message %s found for the details of the error %s", detail, name)
+			}
+		}
+		schemaName := strings.Replace(e, ":", ".", 1)
+		if b.td.SchemaDefinitions == nil {
+			b.td.SchemaDefinitions = map[string]wot.DataSchema{}
+		}
+		b.td.SchemaDefinitions[schemaName] = statusDataSchema(name, code, detailSchema, detailType)
+		responses = append(responses, wot.AdditionalExpectedResponse{Success: false, Schema: schemaName})
+	}
+	return responses, nil
+}
+
+// setFormResponses adds the error responses of the RPCs to the forms, errors of getters are added to forms reading
+// a property and errors of setters to forms writing a property
+func (b *builder) setFormResponses(forms []wot.Form, getErrors, setErrors []string) error {
+	for k, f := range forms {
+		ops, _ := f.Op.([]string)
+		errs := getErrors
+		if contains(ops, "writeproperty") {
+			errs = setErrors
+			if contains(ops, "readproperty") {
+				errs = unionStrings(getErrors, setErrors)
+			}
+		}
+		responses, err := b.additionalResponses(errs)
+		if err != nil {
+			return err
+		}
+		forms[k].AdditionalResponses = responses
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package grpcwot
+
+import (
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+func TestAdditionalResponses(t *testing.T) {
+	dsb := newDataSchemaBuilder()
+	dsb.ds["PreconditionFailure"] = &wot.DataSchema{DataType: "object", ObjectSchema: &wot.ObjectSchema{
+		Properties: map[string]wot.DataSchema{"subject": {DataType: "string"}},
+	}}
+	b := newBuilder("127.0.0.1", 50051, dsb)
+	b.pkg = "acme.v1"
+	responses, err := b.additionalResponses([]string{"NOT_FOUND", "FAILED_PRECONDITION:PreconditionFailure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 || responses[0].Success || responses[0].Schema != "NOT_FOUND" ||
+		responses[1].Schema != "FAILED_PRECONDITION.PreconditionFailure" {
+		t.Errorf("Expected error responses for NOT_FOUND and FAILED_PRECONDITION, but got %v", responses)
+	}
+	notFound := b.td.SchemaDefinitions["NOT_FOUND"]
+	if notFound.ObjectSchema == nil || notFound.Properties["code"].Const != 5 {
+		t.Errorf("Expected the code 5 for NOT_FOUND, but got %v", notFound)
+	}
+	details := b.td.SchemaDefinitions["FAILED_PRECONDITION.PreconditionFailure"].Properties["details"]
+	if details.Items == nil || details.Items.Properties["@type"].Const != "type.googleapis.com/acme.v1.PreconditionFailure" ||
+		details.Items.Properties["subject"].DataType != "string" {
+		t.Errorf("Expected details of type PreconditionFailure, but got %v", details.Items)
+	}
+
+	responses, err = b.additionalResponses([]string{"ABORTED:.acme.v1.PreconditionFailure", "INVALID_ARGUMENT:google.rpc.BadRequest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aborted := b.td.SchemaDefinitions[responses[0].Schema].Properties["details"]
+	if aborted.Items.Properties["@type"].Const != "type.googleapis.com/acme.v1.PreconditionFailure" {
+		t.Errorf("Expected the qualified message of the proto file, but got %v", aborted.Items)
+	}
+	badRequest := b.td.SchemaDefinitions[responses[1].Schema].Properties["details"]
+	if badRequest.Items.Properties["@type"].Const != "type.googleapis.com/google.rpc.BadRequest" ||
+		badRequest.Items.Properties["field_violations"].DataType != "array" {
+		t.Errorf("Expected details of the standard type google.rpc.BadRequest, but got %v", badRequest.Items)
+	}
+
+	if _, err := b.additionalResponses([]string{"NOT_AN_ERROR"}); err == nil {
+		t.Errorf("Expected an error for an unknown status code")
+	}
+	if _, err := b.additionalResponses([]string{"ABORTED:Missing"}); err == nil {
+		t.Errorf("Expected an error for an unknown message of the details")
+	}
+}
+
+func TestSetFormResponses(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, newDataSchemaBuilder())
+	forms := []wot.Form{
+		{Op: []string{"readproperty"}, Href: "Test/Mode"},
+		{Op: []string{"writeproperty"}, Href: "Test/Mode"},
+	}
+	if err := b.setFormResponses(forms, []string{"NOT_FOUND"}, []string{"INVALID_ARGUMENT"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(forms[0].AdditionalResponses) != 1 || forms[0].AdditionalResponses[0].Schema != "NOT_FOUND" {
+		t.Errorf("Expected the errors of the getter for reading, but got %v", forms[0].AdditionalResponses)
+	}
+	if len(forms[1].AdditionalResponses) != 1 || forms[1].AdditionalResponses[0].Schema != "INVALID_ARGUMENT" {
+		t.Errorf("Expected the errors of the setter for writing, but got %v", forms[1].AdditionalResponses)
+	}
+}
@@ -4,8 +4,15 @@ go 1.17
 
 require (
 	github.com/emicklei/proto v1.9.2
-	github.com/linksmart/thing-directory v1.0.0-beta.22
+	github.com/golang/protobuf v1.5.2
+	github.com/gorilla/websocket v1.5.0
+	github.com/jhump/protoreflect v1.12.0
 	github.com/urfave/cli/v2 v2.4.0
+	github.com/xeipuuv/gojsonschema v1.2.0
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
+	google.golang.org/grpc v1.47.0
+	google.golang.org/protobuf v1.28.0
 )
 
 require (
@@ -13,5 +20,7 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
-	github.com/xeipuuv/gojsonschema v1.2.0 // indirect
+	golang.org/x/net v0.0.0-20201021035429-f5854403a974 // indirect
+	golang.org/x/sys v0.0.0-20210119212857-b64e53b001e4 // indirect
+	golang.org/x/text v0.3.3 // indirect
 )
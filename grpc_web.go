@@ -0,0 +1,63 @@
+package grpcwot
+
+import (
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// contentTypeGrpc is the content type of the forms targeting the native gRPC service
+const contentTypeGrpc = "application/grpc+proto"
+
+// grpcWebContentTypes are the content types of gRPC-Web, the binary protobuf format and the base64 encoded text
+// format for clients which cannot read binary streams
+// cf. https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md
+var grpcWebContentTypes = []string{"application/grpc-web+proto", "application/grpc-web-text"}
+
+// grpcWebForms returns the gRPC-Web forms for a form targeting the native gRPC service. gRPC-Web addresses the RPCs
+// by their full method name, so there is a form per operation of the form targeting the RPC bound to the operation,
+// e.g. /acme.lamp.v1.Lamp/GetBrightness, resolved against the base URL of the gRPC-Web endpoint, e.g. an Envoy proxy.
+// The URI variables are fields of the request message and no part of the target IRI
+func grpcWebForms(f wot.Form, bindings []Binding, baseURL string) []wot.Form {
+	href := strings.SplitN(f.Href, "{", 2)[0]
+	var forms []wot.Form
+	for _, op := range f.Ops() {
+		for _, bd := range bindings {
+			if bd.Href != href || bd.Op != op {
+				continue
+			}
+			for _, t := range grpcWebContentTypes {
+				web := f
+				web.Href = strings.TrimSuffix(baseURL, "/") + bd.FullMethod()
+				web.ContentType = t
+				web.Op = []string{op}
+				forms = append(forms, web)
+			}
+		}
+	}
+	return forms
+}
+
+// addGrpcWebForms adds gRPC-Web forms for all gRPC forms of the affordances, so browser clients can consume the TD
+func (b *builder) addGrpcWebForms(baseURL string) {
+	addForms := func(forms []wot.Form) []wot.Form {
+		for _, f := range forms {
+			if f.ContentType == contentTypeGrpc {
+				forms = append(forms, grpcWebForms(f, b.bindings, baseURL)...)
+			}
+		}
+		return forms
+	}
+	for k, v := range b.td.Properties {
+		v.Forms = addForms(v.Forms)
+		b.td.Properties[k] = v
+	}
+	for k, v := range b.td.Actions {
+		v.Forms = addForms(v.Forms)
+		b.td.Actions[k] = v
+	}
+	for k, v := range b.td.Events {
+		v.Forms = addForms(v.Forms)
+		b.td.Events[k] = v
+	}
+}
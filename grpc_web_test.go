@@ -0,0 +1,52 @@
+package grpcwot
+
+import (
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+func TestAddGrpcWebForms(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, newDataSchemaBuilder())
+	b.td.Title = "Lamp"
+	b.pkg = "acme.lamp.v1"
+	b.td.Properties["Brightness"] = wot.PropertyAffordance{InteractionAffordance: wot.InteractionAffordance{
+		Forms: []wot.Form{
+			withSecurity(b.getForms("Brightness", []string{"readproperty", "writeproperty"})[0], []string{"bearer_sc"}),
+			{Href: "v1/brightness", ContentType: "application/json", Op: []string{"readproperty"}, MethodName: "GET"},
+		},
+	}}
+	b.bind("readproperty", "Brightness", "GetBrightness", nil)
+	b.bind("writeproperty", "Brightness", "SetBrightness", nil)
+	b.td.Actions["Toggle"] = wot.ActionAffordance{InteractionAffordance: wot.InteractionAffordance{
+		Forms: b.getForms("Toggle", []string{"invokeaction"}),
+	}}
+	b.bind("invokeaction", "Toggle", "Toggle", nil)
+	b.addGrpcWebForms("https://lamp.example.com/grpc/")
+
+	forms := b.td.Properties["Brightness"].Forms
+	if len(forms) != 6 {
+		t.Fatalf("Expected gRPC-Web forms only for the gRPC form, but got %v", forms)
+	}
+	for k, expected := range []struct{ op, href, contentType string }{
+		{"readproperty", "https://lamp.example.com/grpc/acme.lamp.v1.Lamp/GetBrightness", "application/grpc-web+proto"},
+		{"readproperty", "https://lamp.example.com/grpc/acme.lamp.v1.Lamp/GetBrightness", "application/grpc-web-text"},
+		{"writeproperty", "https://lamp.example.com/grpc/acme.lamp.v1.Lamp/SetBrightness", "application/grpc-web+proto"},
+		{"writeproperty", "https://lamp.example.com/grpc/acme.lamp.v1.Lamp/SetBrightness", "application/grpc-web-text"},
+	} {
+		f := forms[k+2]
+		if f.Href != expected.href || f.ContentType != expected.contentType {
+			t.Errorf("Expected a %s form targeting %s, but got %v", expected.contentType, expected.href, f)
+		}
+		if ops := f.Ops(); len(ops) != 1 || ops[0] != expected.op {
+			t.Errorf("Expected the operation %s, but got %v", expected.op, f.Op)
+		}
+		if s, _ := f.Security.([]string); len(s) != 1 || s[0] != "bearer_sc" {
+			t.Errorf("Expected the security of the gRPC form, but got %v", f.Security)
+		}
+	}
+	if forms := b.td.Actions["Toggle"].Forms; len(forms) != 3 ||
+		forms[1].Href != "https://lamp.example.com/grpc/acme.lamp.v1.Lamp/Toggle" {
+		t.Errorf("Expected gRPC-Web forms targeting the RPC of the action, but got %v", forms)
+	}
+}
@@ -0,0 +1,150 @@
+package grpcwot
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// httpRule is a binding of a RPC to a HTTP method and path by the (google.api.http) option
+// cf. https://github.com/googleapis/googleapis/blob/master/google/api/http.proto
+type httpRule struct {
+	method string
+	path   string
+	body   string
+}
+
+// httpMethods are the fields of a HttpRule which bind the RPC to a HTTP method
+var httpMethods = []string{"get", "put", "post", "delete", "patch"}
+
+// httpRuleLiteral reads a HttpRule, ok is false if the rule does not define a method
+func httpRuleLiteral(l *proto.Literal) (httpRule, bool) {
+	rule := httpRule{}
+	for _, v := range l.OrderedMap {
+		switch {
+		case contains(httpMethods, v.Name):
+			rule.method, rule.path = strings.ToUpper(v.Name), v.Source
+		case v.Name == "custom":
+			for _, c := range v.OrderedMap {
+				switch c.Name {
+				case "kind":
+					rule.method = strings.ToUpper(c.Source)
+				case "path":
+					rule.path = c.Source
+				}
+			}
+		case v.Name == "body":
+			rule.body = v.Source
+		}
+	}
+	return rule, rule.method != "" && rule.path != ""
+}
+
+// httpRules reads the (google.api.http) option of a RPC including its additional bindings
+func httpRules(r *proto.RPC) []httpRule {
+	o, ok := findOption(rpcOptions(r), "google.api.http")
+	if !ok {
+		return nil
+	}
+	var rules []httpRule
+	if rule, ok := httpRuleLiteral(&o.Constant); ok {
+		rules = append(rules, rule)
+	}
+	for _, l := range literalEntries(&o.Constant, "additional_bindings") {
+		if rule, ok := httpRuleLiteral(l); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// pathVariablePattern matches the variables of a path template, e.g. {id} or {name=projects/*/devices/*}
+var pathVariablePattern = regexp.MustCompile(`{([^}=]+)(=[^}]*)?}`)
+
+// fieldDataSchema returns the DataSchema of a (nested) field of a message, e.g. device.id
+func fieldDataSchema(ds *wot.DataSchema, path string) (wot.DataSchema, bool) {
+	var field wot.DataSchema
+	for _, n := range strings.Split(path, ".") {
+		if ds == nil || ds.ObjectSchema == nil {
+			return field, false
+		}
+		f, ok := ds.Properties[n]
+		if !ok {
+			return field, false
+		}
+		field, ds = f, &f
+	}
+	return field, true
+}
+
+// httpForm converts a HttpRule into a form targeting the grpc-gateway at the base URL, the variables of the path
+// template and the fields of the request which are neither bound to the path nor to the body are returned as URI
+// variables of the form
+func httpForm(r affs, rule httpRule, ops []string, baseURL string) (wot.Form, map[string]wot.DataSchema, error) {
+	uriVariables := map[string]wot.DataSchema{}
+	bound := map[string]bool{}
+	var err error
+	href := pathVariablePattern.ReplaceAllStringFunc(strings.TrimPrefix(rule.path, "/"), func(v string) string {
+		m := pathVariablePattern.FindStringSubmatch(v)
+		ds, ok := fieldDataSchema(r.Req, m[1])
+		if !ok || !isScalarDataSchema(ds) {
+			err = errors.New("The variable " + m[1] + " in the HTTP path of RPC " + r.Name +
+				" is not a scalar field of the request")
+		}
+		uriVariables[m[1]] = ds
+		bound[strings.Split(m[1], ".")[0]] = true
+		if m[2] != "" {
+			// the value of a variable with a path pattern spans multiple segments, e.g. projects/p1/devices/d1
+			return "{+" + m[1] + "}"
+		}
+		return "{" + m[1] + "}"
+	})
+	if err != nil {
+		return wot.Form{}, nil, err
+	}
+	if rule.body != "*" && r.Req != nil && r.Req.ObjectSchema != nil {
+		var query []string
+		for k, v := range r.Req.Properties {
+			if !bound[k] && k != rule.body && isScalarDataSchema(v) {
+				query = append(query, k)
+				uriVariables[k] = v
+			}
+		}
+		sort.Strings(query)
+		href += getUriTemplate(query)
+	}
+	return wot.Form{
+		Href:        strings.TrimSuffix(baseURL, "/") + "/" + href,
+		ContentType: "application/json",
+		Op:          ops,
+		MethodName:  rule.method,
+	}, uriVariables, nil
+}
+
+// httpForms returns the forms of the HTTP bindings of the RPC which are served by the grpc-gateway at the base URL,
+// the URI variables of the forms are added to uriVariables. The target IRIs are absolute, as the grpc-gateway is not
+// served at the base of the TD. Without a base URL, no forms are returned
+func httpForms(r affs, ops []string, uriVariables map[string]wot.DataSchema, baseURL string) ([]wot.Form, map[string]wot.DataSchema, error) {
+	if baseURL == "" {
+		return nil, uriVariables, nil
+	}
+	var forms []wot.Form
+	for _, rule := range httpRules(r.RPC) {
+		f, variables, err := httpForm(r, rule, ops, baseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k, v := range variables {
+			if uriVariables == nil {
+				uriVariables = map[string]wot.DataSchema{}
+			}
+			uriVariables[k] = v
+		}
+		forms = append(forms, f)
+	}
+	return forms, uriVariables, nil
+}
@@ -0,0 +1,92 @@
+package grpcwot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+func TestHttpRules(t *testing.T) {
+	def, err := proto.NewParser(strings.NewReader(`syntax = "proto3";
+service Test {
+  rpc Reboot(Request) returns (Request) {
+    option (google.api.http) = {
+      post: "/v1/{name=devices/*}:reboot"
+      body: "*"
+      additional_bindings { custom: { kind: "HEAD" path: "/v1/{name=devices/*}" } }
+    };
+  }
+}`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r *proto.RPC
+	proto.Walk(def, proto.WithRPC(func(v *proto.RPC) { r = v }))
+	expected := []httpRule{
+		{"POST", "/v1/{name=devices/*}:reboot", "*"},
+		{"HEAD", "/v1/{name=devices/*}", ""},
+	}
+	if rules := httpRules(r); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Expected %v, but got %v", expected, rules)
+	}
+}
+
+func TestHttpForm(t *testing.T) {
+	req := &wot.DataSchema{DataType: "object", ObjectSchema: &wot.ObjectSchema{
+		Properties: map[string]wot.DataSchema{
+			"device": {DataType: "object", ObjectSchema: &wot.ObjectSchema{
+				Properties: map[string]wot.DataSchema{"id": {DataType: "string"}},
+			}},
+			"mode":    {DataType: "string"},
+			"verbose": {DataType: "boolean"},
+		},
+	}}
+	r := affs{Name: "SetMode", Req: req}
+	tests := []struct {
+		rule      httpRule
+		href      string
+		variables []string
+	}{
+		{httpRule{"GET", "/v1/devices/{device.id}/mode", ""}, "http://127.0.0.1:8081/v1/devices/{device.id}/mode{?mode,verbose}", []string{"device.id", "mode", "verbose"}},
+		{httpRule{"PUT", "/v1/devices/{device.id}/mode", "mode"}, "http://127.0.0.1:8081/v1/devices/{device.id}/mode{?verbose}", []string{"device.id", "verbose"}},
+		{httpRule{"PATCH", "/v1/{device.id=devices/*}", "*"}, "http://127.0.0.1:8081/v1/{+device.id}", []string{"device.id"}},
+	}
+	for _, tt := range tests {
+		f, variables, err := httpForm(r, tt.rule, []string{"writeproperty"}, "http://127.0.0.1:8081/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Href != tt.href || f.MethodName != tt.rule.method || f.ContentType != "application/json" {
+			t.Errorf("Expected the form %s %s, but got %v", tt.rule.method, tt.href, f)
+		}
+		if len(variables) != len(tt.variables) {
+			t.Errorf("Expected the URI variables %v, but got %v", tt.variables, variables)
+		}
+		for _, v := range tt.variables {
+			if _, ok := variables[v]; !ok {
+				t.Errorf("Expected the URI variable %s, but got %v", v, variables)
+			}
+		}
+	}
+
+	if _, _, err := httpForm(r, httpRule{"GET", "/v1/{device}", ""}, []string{"readproperty"}, "http://127.0.0.1:8081"); err == nil {
+		t.Errorf("Expected an error for a path variable which is not a scalar field")
+	}
+}
+
+func TestHTTPFormsWithoutGateway(t *testing.T) {
+	r := affs{Name: "GetMode", RPC: &proto.RPC{Name: "GetMode", Elements: []proto.Visitee{
+		aggregateOption("(google.api.http)", map[string]string{"get": "/v1/mode"}),
+	}}}
+	forms, _, err := httpForms(r, []string{"readproperty"}, nil, "")
+	if err != nil || len(forms) != 0 {
+		t.Errorf("Expected no HTTP forms without the base URL of the grpc-gateway, but got %v, %v", forms, err)
+	}
+	forms, _, err = httpForms(r, []string{"readproperty"}, nil, "http://127.0.0.1:8081")
+	if err != nil || len(forms) != 1 || forms[0].Href != "http://127.0.0.1:8081/v1/mode" {
+		t.Errorf("Expected the HTTP form targeting the grpc-gateway, but got %v, %v", forms, err)
+	}
+}
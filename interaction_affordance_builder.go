@@ -2,8 +2,9 @@ package grpcwot
 
 import (
 	"errors"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
 	"github.com/emicklei/proto"
-	"github.com/linksmart/thing-directory/wot"
+	"sort"
 	"strings"
 )
 
@@ -13,6 +14,9 @@ type interactionAffordanceBuilder struct {
 	dsb  *dataSchemaBuilder
 	affC affClasses
 	cats catProps
+
+	// GetOperation and CancelOperation RPCs of the service, cf. isOperationRPC
+	operations map[string]*proto.RPC
 }
 
 type catProps struct {
@@ -39,6 +43,7 @@ type affs struct {
 	Name string
 	Req  *wot.DataSchema
 	Res  *wot.DataSchema
+	RPC  *proto.RPC
 }
 
 func newInteractionAffordanceBuilder(dsb *dataSchemaBuilder) *interactionAffordanceBuilder {
@@ -53,10 +58,11 @@ func newInteractionAffordanceBuilder(dsb *dataSchemaBuilder) *interactionAfforda
 			[]affs{},
 		},
 		catProps{
-			or(startsWithGetCaseInsensitive, startsWithSetCaseInsensitive),
+			or(or(startsWithGetCaseInsensitive, startsWithSetCaseInsensitive), hasNoSideEffects),
 			defaultConfig,
 			and(not(hasRequestType), hasReturnType),
 		},
+		map[string]*proto.RPC{},
 	}
 }
 
@@ -68,6 +74,10 @@ func (b *interactionAffordanceBuilder) HandleRPC(r *proto.RPC) {
 func (b *interactionAffordanceBuilder) conformRPCs() error {
 	b.affs = map[string]affs{}
 	for _, v := range b.rpcs {
+		if isOperationRPC(v) {
+			b.operations[v.Name] = v
+			continue
+		}
 		if _, found := b.affs[v.Name]; found {
 			return errors.New("Duplicate RPC name found in proto file for RPC Name " + v.Name)
 		}
@@ -75,14 +85,21 @@ func (b *interactionAffordanceBuilder) conformRPCs() error {
 		if !found {
 			return errors.New("Not able to determine message for request type " + v.RequestType + " in RPC " + v.Name)
 		}
-		res, found := b.dsb.ds[v.ReturnsType]
-		if !found {
+		var res *wot.DataSchema
+		if isLongRunning(v) {
+			// the output of an asynchronous action is the result of the operation
+			var err error
+			if res, err = b.dsb.operationResponse(v); err != nil {
+				return err
+			}
+		} else if res, found = b.dsb.ds[v.ReturnsType]; !found {
 			return errors.New("Not able to determine message for return type " + v.ReturnsType + " in RPC " + v.Name)
 		}
 		b.affs[v.Name] = affs{
 			v.Name,
 			req,
 			res,
+			v,
 		}
 	}
 	return nil
@@ -96,6 +113,14 @@ func (b *interactionAffordanceBuilder) groupProperties() {
 			continue
 		}
 		b.affC.prop[k] = empty
+		if !startsWithGetCaseInsensitive(v) && !startsWithSetCaseInsensitive(v) {
+			b.affC.combinedProp = append(b.affC.combinedProp, combinedProperties{
+				Name:     v.Name,
+				GetProp:  v,
+				Category: 0,
+			})
+			continue
+		}
 		b.checkPropertyCombination(v, "GET", "SET", true, empty)
 		b.checkPropertyCombination(v, "SET", "GET", false, empty)
 	}
@@ -147,6 +172,45 @@ func (b *interactionAffordanceBuilder) checkPropertyCombination(p affs, s1, s2 s
 	}
 }
 
+// groupPropertiesWithConfig groups together the properties which share the same affordance name in the configuration
+func (b *interactionAffordanceBuilder) groupPropertiesWithConfig(ac map[string]affClassConfig) {
+	index := map[string]int{}
+	for _, v := range b.affC.prop {
+		propName := ac[v.Name].Name
+		if propName == "" {
+			propName = v.Name
+		}
+		k, found := index[propName]
+		if !found {
+			b.affC.combinedProp = append(b.affC.combinedProp, combinedProperties{Name: propName})
+			k = len(b.affC.combinedProp) - 1
+			index[propName] = k
+		}
+		if isPropertySetter(v) {
+			b.affC.combinedProp[k].SetProp = v
+		} else {
+			b.affC.combinedProp[k].GetProp = v
+		}
+	}
+	for k, v := range b.affC.combinedProp {
+		b.affC.combinedProp[k].Category = getPropertyCategory(v.GetProp.Name, v.SetProp.Name)
+	}
+	b.affC.prop = []affs{}
+}
+
+// isPropertySetter determines if the RPC writes a property. RPCs which are not named after the Get/Set convention
+// are considered as setter if they only take a request without returning a response
+func isPropertySetter(a affs) bool {
+	switch {
+	case startsWithGetCaseInsensitive(a):
+		return false
+	case startsWithSetCaseInsensitive(a):
+		return true
+	default:
+		return hasRequestType(a) && !hasReturnType(a)
+	}
+}
+
 // Determines the category for a properts (0: readonly, 1: writeonly, 2: readwrite)
 func getPropertyCategory(get, set string) int {
 	switch {
@@ -182,6 +246,10 @@ func startsWithSet(a affs) bool {
 	return strings.HasPrefix(a.Name, "Set")
 }
 
+func hasNoSideEffects(a affs) bool {
+	return hasOptionEffect(a.RPC, effectReadable)
+}
+
 func typeNotEmpty(t *wot.DataSchema) bool {
 	return t.ObjectSchema != nil &&
 		t.Properties != nil &&
@@ -214,10 +282,41 @@ func not(condition checkCondition) checkCondition {
 	}
 }
 
+// orderedAffs returns the affordances in the order of the declaration of their RPCs in the proto file, so the
+// classification does not depend on the iteration order of the map affs. Affordances without a declared RPC follow
+// sorted by name
+func (b *interactionAffordanceBuilder) orderedAffs() []affs {
+	declared := make(map[string]int, len(b.rpcs))
+	for k, r := range b.rpcs {
+		declared[r.Name] = k
+	}
+	position := func(name string) int {
+		if k, ok := declared[name]; ok {
+			return k
+		}
+		return len(b.rpcs)
+	}
+	names := make([]string, 0, len(b.affs))
+	for k := range b.affs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	sort.SliceStable(names, func(i, j int) bool { return position(names[i]) < position(names[j]) })
+	ordered := make([]affs, len(names))
+	for k, n := range names {
+		ordered[k] = b.affs[n]
+	}
+	return ordered
+}
+
 // Apply checkConditions and filter properties -> events -> actions
+// RPCs annotated with the (wot.affordance) option are skipped, cf. categorizeAnnotatedRPCs
 func (b *interactionAffordanceBuilder) categorizeRPCs() {
-	for _, v := range b.affs {
+	for _, v := range b.orderedAffs() {
 		switch {
+		case isAnnotated(v):
+		case isLongRunning(v.RPC):
+			b.affC.action = append(b.affC.action, v)
 		case b.cats.prop(v):
 			b.affC.prop = append(b.affC.prop, v)
 		case b.cats.event(v):
@@ -228,11 +327,48 @@ func (b *interactionAffordanceBuilder) categorizeRPCs() {
 	}
 }
 
+// annotatedClassification returns the classification of all RPCs annotated with the (wot.affordance) option
+func (b *interactionAffordanceBuilder) annotatedClassification() map[string]affClassConfig {
+	ac := map[string]affClassConfig{}
+	for k, v := range b.affs {
+		if c, ok := annotatedClassConfig(v.RPC); ok {
+			ac[k] = c
+		}
+	}
+	return ac
+}
+
+// categorizeAnnotatedRPCs classifies the RPCs annotated with the (wot.affordance) option like a configuration would
+// do and adds them to the classified affordances
+func (b *interactionAffordanceBuilder) categorizeAnnotatedRPCs() error {
+	ac := b.annotatedClassification()
+	if len(ac) == 0 {
+		return nil
+	}
+	annotated := newInteractionAffordanceBuilder(b.dsb)
+	for _, v := range b.orderedAffs() {
+		if _, ok := ac[v.Name]; ok {
+			annotated.rpcs = append(annotated.rpcs, v.RPC)
+			annotated.affs[v.Name] = v
+		}
+	}
+	err := annotated.categorizeRPCsWithConfig(ac)
+	if err != nil {
+		return err
+	}
+	annotated.groupPropertiesWithConfig(ac)
+
+	b.affC.combinedProp = append(b.affC.combinedProp, annotated.affC.combinedProp...)
+	b.affC.action = append(b.affC.action, annotated.affC.action...)
+	b.affC.event = append(b.affC.event, annotated.affC.event...)
+	return nil
+}
+
 // HandleRPCWithConfig classifies RPC functions to interaction affordances based on a provided configuration
 func (b *interactionAffordanceBuilder) categorizeRPCsWithConfig(ac map[string]affClassConfig) error {
 	processed := make([]string, len(ac))
 	i := 0
-	for _, v := range b.affs {
+	for _, v := range b.orderedAffs() {
 		c, ok := ac[v.Name]
 		if !ok {
 			return errors.New("Could not find pre configured classification for RPC " + v.Name)
@@ -244,14 +380,14 @@ func (b *interactionAffordanceBuilder) categorizeRPCsWithConfig(ac map[string]af
 		case "property":
 			b.affC.prop = append(b.affC.prop, v)
 		case "action":
-			b.affC.event = append(b.affC.event, v)
-		case "event":
 			b.affC.action = append(b.affC.action, v)
+		case "event":
+			b.affC.event = append(b.affC.event, v)
 		default:
 			return errors.New("Defined AffClass which is not possible " + c.AffClass)
 		}
 	}
-	if i != len(ac)-1 {
+	if i != len(ac) {
 		m := "Processed not all configs. Only the following RPCs were in the proto: "
 		for _, e := range processed {
 			m = m + e + ", "
@@ -277,6 +413,11 @@ func generateInteractionAffordances(protoFile *proto.Proto, dsb *dataSchemaBuild
 
 	b.groupProperties()
 
+	err = b.categorizeAnnotatedRPCs()
+	if err != nil {
+		return nil, err
+	}
+
 	return b, nil
 }
 
@@ -287,9 +428,24 @@ func generateInteractionAffordancesWithConfig(protoFile *proto.Proto, dsb *dataS
 	proto.Walk(protoFile,
 		proto.WithRPC(b.HandleRPC))
 
-	err := b.categorizeRPCsWithConfig(ac)
+	err := b.conformRPCs()
+	if err != nil {
+		return nil, err
+	}
+
+	// RPCs which are not in the configuration fall back to the classification of their (wot.affordance) option
+	for k, v := range b.annotatedClassification() {
+		if _, ok := ac[k]; !ok {
+			ac[k] = v
+		}
+	}
+
+	err = b.categorizeRPCsWithConfig(ac)
 	if err != nil {
 		return nil, err
 	}
+
+	b.groupPropertiesWithConfig(ac)
+
 	return b, nil
 }
@@ -2,8 +2,9 @@ package grpcwot
 
 import (
 	"errors"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
 	"github.com/emicklei/proto"
-	"github.com/linksmart/thing-directory/wot"
+	"strings"
 	"testing"
 )
 
@@ -584,3 +585,221 @@ func equalsCombinedProps(a combinedProperties, b combinedProperties) bool {
 		a.Name == b.Name &&
 		a.Category == b.Category
 }
+
+var groupPropertiesWithConfigTest = []struct {
+	inIab    interactionAffordanceBuilder
+	inConfig map[string]affClassConfig
+	out      affClasses
+}{
+	{
+		interactionAffordanceBuilder{
+			affC: affClasses{
+				prop: []affs{
+					combinePropertiesTestAffordances["GetTest2WithDifferentResAsSet"],
+					combinePropertiesTestAffordances["SetTest2WithDifferentReqAsGet"],
+				},
+			},
+		},
+		map[string]affClassConfig{
+			"GetTest2": {AffClass: "property", Name: "Test"},
+			"SetTest2": {AffClass: "property", Name: "Test"},
+		},
+		affClasses{
+			combinedProp: []combinedProperties{
+				{
+					Name:     "Test",
+					GetProp:  combinePropertiesTestAffordances["GetTest2WithDifferentResAsSet"],
+					SetProp:  combinePropertiesTestAffordances["SetTest2WithDifferentReqAsGet"],
+					Category: 2,
+				},
+			},
+		},
+	},
+	{
+		interactionAffordanceBuilder{
+			affC: affClasses{
+				prop: []affs{
+					categorizeRPCTestAffordances["TestWithRequest"],
+					categorizeRPCTestAffordances["TestWithReturn"],
+				},
+			},
+		},
+		map[string]affClassConfig{
+			"TestWithRequest": {AffClass: "property"},
+			"TestWithReturn":  {AffClass: "property", Name: "Test"},
+		},
+		affClasses{
+			combinedProp: []combinedProperties{
+				{
+					Name:     "TestWithRequest",
+					SetProp:  categorizeRPCTestAffordances["TestWithRequest"],
+					Category: 1,
+				},
+				{
+					Name:     "Test",
+					GetProp:  categorizeRPCTestAffordances["TestWithReturn"],
+					Category: 0,
+				},
+			},
+		},
+	},
+}
+
+func TestGroupPropertiesWithConfig(t *testing.T) {
+	for _, v := range groupPropertiesWithConfigTest {
+		v.inIab.groupPropertiesWithConfig(v.inConfig)
+		equalsCombinedPropsSlice(v.out.combinedProp, v.inIab.affC.combinedProp, t)
+	}
+}
+
+func TestCategorizeRPCWithConfig(t *testing.T) {
+	iab := interactionAffordanceBuilder{
+		affs: map[string]affs{
+			"SimpleTest": categorizeRPCTestAffordances["SimpleTest"],
+			"GetTest":    categorizeRPCTestAffordances["GetTest"],
+			"SetTest":    categorizeRPCTestAffordances["SetTest"],
+		},
+	}
+	err := iab.categorizeRPCsWithConfig(map[string]affClassConfig{
+		"SimpleTest": {AffClass: "event"},
+		"GetTest":    {AffClass: "action"},
+		"SetTest":    {AffClass: "property"},
+	})
+	errorCheck(t, nil, err)
+	equals([]affs{categorizeRPCTestAffordances["SetTest"]}, iab.affC.prop, t)
+	equals([]affs{categorizeRPCTestAffordances["GetTest"]}, iab.affC.action, t)
+	equals([]affs{categorizeRPCTestAffordances["SimpleTest"]}, iab.affC.event, t)
+
+	err = iab.categorizeRPCsWithConfig(map[string]affClassConfig{
+		"SimpleTest": {AffClass: "event"},
+		"SetTest":    {AffClass: "property"},
+	})
+	errorCheck(t, errors.New("Could not find pre configured classification for RPC GetTest"), err)
+}
+
+func TestLongRunningOperations(t *testing.T) {
+	src := `syntax = "proto3";
+service Backup {
+  rpc CreateBackup(Request) returns (google.longrunning.Operation) {
+    option (google.longrunning.operation_info) = { response_type: "acme.v1.Result" metadata_type: "Metadata" };
+  }
+  rpc Restore(Request) returns (google.longrunning.Operation);
+  rpc GetResult(Request) returns (Result) {
+    option (google.longrunning.operation_info) = { response_type: "Result" };
+  }
+  rpc GetOperation(google.longrunning.GetOperationRequest) returns (google.longrunning.Operation);
+}
+message Request { string name = 1; }
+message Result { int32 size = 1; }`
+	definition, err := proto.NewParser(strings.NewReader(src)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsb, err := generateDataSchemas(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := generateInteractionAffordances(definition, dsb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.affs["GetOperation"]; ok {
+		t.Errorf("Expected GetOperation not to be an affordance")
+	}
+	if _, ok := b.operations["GetOperation"]; !ok {
+		t.Errorf("Expected GetOperation to be recorded as operation RPC")
+	}
+	if len(b.affC.action) != 3 || len(b.affC.combinedProp) != 0 {
+		t.Errorf("Expected all long-running RPCs to be actions, but got %v", b.affC)
+	}
+	if b.affs["CreateBackup"].Res != dsb.ds["Result"] {
+		t.Errorf("Expected the output of CreateBackup to be the response_type Result, but got %v", b.affs["CreateBackup"].Res)
+	}
+	if _, ok := b.affs["Restore"].Res.Properties["done"]; !ok {
+		t.Errorf("Expected the output of Restore to be the Operation, but got %v", b.affs["Restore"].Res)
+	}
+
+	definition, err = proto.NewParser(strings.NewReader(`syntax = "proto3";
+service Backup {
+  rpc CreateBackup(Request) returns (google.longrunning.Operation) {
+    option (google.longrunning.operation_info) = { response_type: "Missing" };
+  }
+}
+message Request { string name = 1; }`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsb, err = generateDataSchemas(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = generateInteractionAffordances(definition, dsb)
+	errorCheck(t, errors.New("no message Missing found for the response_type of the (google.longrunning.operation_info) "+
+		"option of the RPC CreateBackup"), err)
+}
+
+func TestInvokeResponse(t *testing.T) {
+	output := &wot.DataSchema{DataType: "object"}
+	synchronous := false
+	if r := InvokeResponse(wot.ActionAffordance{Output: output}); r != output {
+		t.Errorf("Expected the output of a synchronous action, but got %v", r)
+	}
+	r := InvokeResponse(wot.ActionAffordance{Output: output, Synchronous: &synchronous})
+	if _, ok := r.Properties["done"]; !ok {
+		t.Errorf("Expected the Operation of an asynchronous action, but got %v", r)
+	}
+}
+
+func TestDeclarationOrder(t *testing.T) {
+	src := `syntax = "proto3";
+service Lamp {
+  rpc Toggle(Empty) returns (Empty);
+  rpc GetLevel(Empty) returns (Level);
+  rpc Blink(Level) returns (Empty);
+  rpc SetLevel(Level) returns (Empty);
+  rpc Dim(Level) returns (Level);
+  rpc Alarm(Empty) returns (stream Level);
+  rpc Reset(Empty) returns (Empty);
+  rpc GetMode(Empty) returns (Level);
+}
+message Empty {}
+message Level { int32 value = 1; }`
+	definition, err := proto.NewParser(strings.NewReader(src)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(a []affs) []string {
+		var n []string
+		for _, v := range a {
+			n = append(n, v.Name)
+		}
+		return n
+	}
+	var want *affClasses
+	for i := 0; i < 20; i++ {
+		dsb, err := generateDataSchemas(definition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := generateInteractionAffordances(definition, dsb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = &b.affC
+			continue
+		}
+		if got := names(b.affC.action); strings.Join(got, ",") != strings.Join(names(want.action), ",") {
+			t.Fatalf("Expected the actions in the same order on every run, but got %v and %v", got, names(want.action))
+		}
+		for k, v := range b.affC.combinedProp {
+			if v.Name != want.combinedProp[k].Name {
+				t.Fatalf("Expected the properties in the same order on every run, but got %v and %v",
+					b.affC.combinedProp, want.combinedProp)
+			}
+		}
+	}
+	if got := strings.Join(names(want.action), ","); got != "Toggle,Blink,Dim,Reset" {
+		t.Errorf("Expected the actions in declaration order, but got %s", got)
+	}
+}
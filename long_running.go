@@ -0,0 +1,147 @@
+package grpcwot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// operationType is the message returned by RPCs which start a long-running operation
+// cf. https://github.com/googleapis/googleapis/blob/master/google/longrunning/operations.proto
+const operationType = "google.longrunning.Operation"
+
+// operationsService is the standard service to manage long-running operations, it is used for the forms of
+// asynchronous actions if the service does not define the GetOperation and CancelOperation RPCs itself
+const operationsService = "google.longrunning.Operations"
+
+// operationRPCs are the RPCs of the service to query and cancel long-running operations, mapped to their operation
+// type in the TD
+var operationRPCs = map[string]string{
+	"GetOperation":    "queryaction",
+	"CancelOperation": "cancelaction",
+}
+
+// operationInfo reads the response_type and metadata_type of the (google.longrunning.operation_info) option
+func operationInfo(r *proto.RPC) (responseType, metadataType string, ok bool) {
+	o, ok := findOption(rpcOptions(r), "google.longrunning.operation_info")
+	if !ok {
+		return "", "", false
+	}
+	for _, v := range o.Constant.OrderedMap {
+		switch v.Name {
+		case "response_type":
+			responseType = v.Source
+		case "metadata_type":
+			metadataType = v.Source
+		}
+	}
+	return responseType, metadataType, true
+}
+
+// isLongRunning determines if the RPC starts a long-running operation, i.e. it returns a google.longrunning.Operation
+// or is annotated with the (google.longrunning.operation_info) option
+func isLongRunning(r *proto.RPC) bool {
+	if r == nil {
+		return false
+	}
+	if r.ReturnsType == operationType || r.ReturnsType == "."+operationType {
+		return true
+	}
+	_, _, ok := operationInfo(r)
+	return ok
+}
+
+// isOperationRPC determines if the RPC is the GetOperation or CancelOperation RPC of the service, which is not an
+// affordance of its own but the target of the forms of the asynchronous actions
+func isOperationRPC(r *proto.RPC) bool {
+	if _, ok := operationRPCs[r.Name]; !ok {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimPrefix(r.RequestType, "."), "google.longrunning.")
+}
+
+// lookupMessage returns the DataSchema of a message, the name may be qualified with the package, e.g. acme.v1.Result
+func (b *dataSchemaBuilder) lookupMessage(name string) (*wot.DataSchema, bool) {
+	name = strings.TrimPrefix(name, ".")
+	for {
+		if ds, ok := b.ds[name]; ok {
+			return ds, true
+		}
+		k := strings.Index(name, ".")
+		if k == -1 {
+			return nil, false
+		}
+		name = name[k+1:]
+	}
+}
+
+// operationDataSchema describes a google.longrunning.Operation, if the type of the result is not known
+func operationDataSchema() *wot.DataSchema {
+	anySchema := wot.DataSchema{
+		DataType: "object",
+		ObjectSchema: &wot.ObjectSchema{
+			Properties: map[string]wot.DataSchema{"@type": {DataType: "string"}},
+			Required:   []string{"@type"},
+		},
+	}
+	status := statusDataSchema("Status", 0, nil, "")
+	status.Title = ""
+	status.Properties["code"] = wot.DataSchema{DataType: "integer"}
+	return &wot.DataSchema{
+		DataType: "object",
+		ObjectSchema: &wot.ObjectSchema{
+			Properties: map[string]wot.DataSchema{
+				"name":     {DataType: "string"},
+				"metadata": anySchema,
+				"done":     {DataType: "boolean"},
+				"error":    status,
+				"response": anySchema,
+			},
+		},
+	}
+}
+
+// operationResponse returns the DataSchema of the result of a long-running operation, it is the message given as
+// response_type of the (google.longrunning.operation_info) option or the Operation itself
+func (b *dataSchemaBuilder) operationResponse(r *proto.RPC) (*wot.DataSchema, error) {
+	responseType, _, ok := operationInfo(r)
+	if !ok || responseType == "" {
+		return operationDataSchema(), nil
+	}
+	ds, ok := b.lookupMessage(responseType)
+	if !ok {
+		return nil, fmt.Errorf("no message %s found for the response_type of the (google.longrunning.operation_info) "+
+			"option of the RPC %s", responseType, r.Name)
+	}
+	return ds, nil
+}
+
+// InvokeResponse returns the DataSchema of the response to invoking the action through its RPC. Synchronous actions
+// respond with their output, whereas the RPC of an asynchronous action responds with the google.longrunning.Operation
+// it started. The output of an asynchronous action is the result of the operation, once it is done
+func InvokeResponse(a wot.ActionAffordance) *wot.DataSchema {
+	if a.Synchronous != nil && !*a.Synchronous {
+		return operationDataSchema()
+	}
+	return a.Output
+}
+
+// operationForms returns the forms to query and cancel the operation started by an asynchronous action,
+// they target the GetOperation and CancelOperation RPCs of the service or of the standard Operations service
+func (b *builder) operationForms() []wot.Form {
+	var forms []wot.Form
+	for _, n := range []string{"GetOperation", "CancelOperation"} {
+		href := operationsService + "/" + n
+		if _, ok := b.iab.operations[n]; ok {
+			href = b.GetIRI(n)
+		}
+		forms = append(forms, wot.Form{
+			Href:        href,
+			ContentType: contentTypeGrpc,
+			Op:          []string{operationRPCs[n]},
+		})
+	}
+	return forms
+}
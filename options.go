@@ -0,0 +1,271 @@
+package grpcwot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// optionEffect describes how an option of a RPC is reflected in the TD
+type optionEffect int
+
+const (
+	effectSafe       optionEffect = iota // the action does not change the state of the Thing
+	effectIdempotent                     // the action can be invoked repeatedly with the same result
+	effectReadable                       // the RPC is proposed as readable property by the classification
+	effectDeprecated                     // the affordance is annotated as deprecated
+)
+
+// optionMapping maps an option of a RPC with a specific value to an effect on the interaction affordance
+type optionMapping struct {
+	option string
+	value  string
+	effect optionEffect
+}
+
+// optionMappings is the table which translates the options of RPCs into the metadata of the TD
+// cf. https://github.com/protocolbuffers/protobuf/blob/main/src/google/protobuf/descriptor.proto (MethodOptions)
+var optionMappings = []optionMapping{
+	{"idempotency_level", "NO_SIDE_EFFECTS", effectSafe},
+	{"idempotency_level", "NO_SIDE_EFFECTS", effectIdempotent},
+	{"idempotency_level", "NO_SIDE_EFFECTS", effectReadable},
+	{"idempotency_level", "IDEMPOTENT", effectIdempotent},
+	{"deprecated", "true", effectDeprecated},
+}
+
+// rpcOptions returns the options defined in the body of a RPC
+func rpcOptions(r *proto.RPC) []*proto.Option {
+	if r == nil {
+		return nil
+	}
+	var opts []*proto.Option
+	for _, e := range r.Elements {
+		if o, ok := e.(*proto.Option); ok {
+			opts = append(opts, o)
+		}
+	}
+	return opts
+}
+
+// hasOptionEffect determines if one of the options of the RPC leads to the effect according to optionMappings
+func hasOptionEffect(r *proto.RPC, effect optionEffect) bool {
+	for _, o := range rpcOptions(r) {
+		for _, m := range optionMappings {
+			if m.effect == effect && m.option == o.Name && m.value == o.Constant.Source {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deprecatedDescription annotates the description of an affordance as deprecated
+func deprecatedDescription(description string) string {
+	if description == "" {
+		return "Deprecated"
+	}
+	return "Deprecated. " + description
+}
+
+// wotAffordance holds the (wot.affordance) option of a RPC defined in wot/options.proto
+type wotAffordance struct {
+	kind        string
+	name        string
+	observable  bool
+	readOnly    bool
+	title       string
+	description string
+	security    []string
+	errors      []string
+	// hasReadOnly and hasObservable are set if read_only and observable are given explicitly, so that the option
+	// overrides the directive
+	hasReadOnly   bool
+	hasObservable bool
+}
+
+// optionName normalizes the name of a custom option, i.e. (.wot.field) and (wot.field) both become wot.field
+func optionName(o *proto.Option) string {
+	n := strings.Replace(strings.Replace(o.Name, "(", "", 1), ")", "", 1)
+	return strings.TrimPrefix(n, ".")
+}
+
+// findOption returns the first option with the given (normalized) name
+func findOption(opts []*proto.Option, name string) (*proto.Option, bool) {
+	for _, o := range opts {
+		if optionName(o) == name {
+			return o, true
+		}
+	}
+	return nil, false
+}
+
+// literalBool interprets a literal as boolean
+func literalBool(l *proto.Literal) bool {
+	return l.Source == "true"
+}
+
+// protoEscapes rewrites the source of a proto string literal, which can be quoted with ' or ", to the content of a
+// Go string literal quoted with ". The escaped backslash is kept so it does not start another escape
+var protoEscapes = strings.NewReplacer(`\\`, `\\`, `\?`, `?`, `\'`, `'`, `\"`, `\"`, `"`, `\"`)
+
+// literalString interprets a literal as string, the escapes of a string literal are resolved, e.g. "\\d" results
+// in \d. The source is returned if it is no valid string literal
+func literalString(l *proto.Literal) string {
+	if !l.IsString {
+		return l.Source
+	}
+	s, err := strconv.Unquote(`"` + protoEscapes.Replace(l.Source) + `"`)
+	if err != nil {
+		return l.Source
+	}
+	return s
+}
+
+// literalFloat interprets a literal as floating point number
+func literalFloat(l *proto.Literal) (float64, bool) {
+	f, err := strconv.ParseFloat(l.Source, 64)
+	return f, err == nil
+}
+
+// affordanceOption reads the (wot.affordance) option of a RPC
+func affordanceOption(r *proto.RPC) (wotAffordance, bool) {
+	o, ok := findOption(rpcOptions(r), "wot.affordance")
+	if !ok {
+		return wotAffordance{}, false
+	}
+	a := wotAffordance{}
+	for _, v := range o.Constant.OrderedMap {
+		switch v.Name {
+		case "kind":
+			a.kind = v.Source
+		case "name":
+			a.name = literalString(v.Literal)
+		case "observable":
+			a.observable = literalBool(v.Literal)
+			a.hasObservable = true
+		case "read_only":
+			a.readOnly = literalBool(v.Literal)
+			a.hasReadOnly = true
+		case "title":
+			a.title = literalString(v.Literal)
+		case "description":
+			a.description = literalString(v.Literal)
+		}
+	}
+	a.security = literalStrings(&o.Constant, "security")
+	a.errors = literalStrings(&o.Constant, "errors")
+	return a, true
+}
+
+// affordanceAnnotation combines the comment directive and the (wot.affordance) option of a RPC, where the values of the
+// option take precedence over the directive
+func affordanceAnnotation(r *proto.RPC) (wotAffordance, bool) {
+	a, isDirective := affordanceDirective(r)
+	o, isOption := affordanceOption(r)
+	if isOption {
+		if o.kind != "" {
+			a.kind = o.kind
+		}
+		if o.name != "" {
+			a.name = o.name
+		}
+		if o.title != "" {
+			a.title = o.title
+		}
+		if o.description != "" {
+			a.description = o.description
+		}
+		if len(o.security) != 0 {
+			a.security = o.security
+		}
+		if len(o.errors) != 0 {
+			a.errors = o.errors
+		}
+		if o.hasReadOnly {
+			a.readOnly = o.readOnly
+		}
+		if o.hasObservable {
+			a.observable = o.observable
+		}
+	}
+	return a, isDirective || isOption
+}
+
+// annotatedClassConfig derives the classification of a RPC from its (wot.affordance) option or comment directive
+func annotatedClassConfig(r *proto.RPC) (affClassConfig, bool) {
+	a, ok := affordanceAnnotation(r)
+	if !ok {
+		return affClassConfig{}, false
+	}
+	c := affClassConfig{Name: a.name}
+	switch a.kind {
+	case "PROPERTY":
+		c.AffClass = "property"
+		if c.Name == "" {
+			// the getter and setter of a property are paired like by the heuristic classification
+			c.Name = propertyName(r.Name)
+		}
+	case "ACTION":
+		c.AffClass = "action"
+	case "EVENT":
+		c.AffClass = "event"
+	default:
+		return affClassConfig{}, false
+	}
+	return c, true
+}
+
+// propertyName returns the name of the property of a RPC following the Get/Set convention, e.g. Mode for GetMode,
+// other RPCs are named by themselves
+func propertyName(rpc string) string {
+	upper := strings.ToUpper(rpc)
+	if len(rpc) > 3 && (strings.HasPrefix(upper, "GET") || strings.HasPrefix(upper, "SET")) {
+		return rpc[3:]
+	}
+	return rpc
+}
+
+// isAnnotated determines if the classification of the RPC is defined by the (wot.affordance) option or a directive
+func isAnnotated(a affs) bool {
+	_, ok := annotatedClassConfig(a.RPC)
+	return ok
+}
+
+// applyFieldOption refines the DataSchema of a field according to its (wot.field) option
+func applyFieldOption(ds *wot.DataSchema, f *proto.Field) {
+	o, ok := findOption(f.Options, "wot.field")
+	if !ok {
+		return
+	}
+	for _, v := range o.Constant.OrderedMap {
+		switch v.Name {
+		case "unit":
+			ds.Unit = v.Source
+		case "title":
+			ds.Title = v.Source
+		case "description":
+			ds.Description = v.Source
+		case "format":
+			ds.Format = v.Source
+		case "read_only":
+			ds.ReadOnly = literalBool(v.Literal)
+		case "write_only":
+			ds.WriteOnly = literalBool(v.Literal)
+		case "minimum", "maximum":
+			f, ok := literalFloat(v.Literal)
+			if !ok {
+				continue
+			}
+			if ds.NumberSchema == nil {
+				ds.NumberSchema = &wot.NumberSchema{}
+			}
+			if v.Name == "minimum" {
+				ds.Minimum = &f
+			} else {
+				ds.Maximum = &f
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package grpcwot
+
+import (
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+func rpcWithOption(name, option, value string) *proto.RPC {
+	return &proto.RPC{
+		Name: name,
+		Elements: []proto.Visitee{
+			&proto.Option{Name: option, Constant: proto.Literal{Source: value}},
+		},
+	}
+}
+
+var hasOptionEffectTest = []struct {
+	in     *proto.RPC
+	effect optionEffect
+	out    bool
+}{
+	{rpcWithOption("Test", "idempotency_level", "NO_SIDE_EFFECTS"), effectSafe, true},
+	{rpcWithOption("Test", "idempotency_level", "NO_SIDE_EFFECTS"), effectIdempotent, true},
+	{rpcWithOption("Test", "idempotency_level", "NO_SIDE_EFFECTS"), effectReadable, true},
+	{rpcWithOption("Test", "idempotency_level", "IDEMPOTENT"), effectSafe, false},
+	{rpcWithOption("Test", "idempotency_level", "IDEMPOTENT"), effectIdempotent, true},
+	{rpcWithOption("Test", "idempotency_level", "IDEMPOTENT"), effectReadable, false},
+	{rpcWithOption("Test", "deprecated", "true"), effectDeprecated, true},
+	{rpcWithOption("Test", "deprecated", "false"), effectDeprecated, false},
+	{&proto.RPC{Name: "Test"}, effectIdempotent, false},
+	{nil, effectSafe, false},
+}
+
+func TestHasOptionEffect(t *testing.T) {
+	for _, tt := range hasOptionEffectTest {
+		result := hasOptionEffect(tt.in, tt.effect)
+		if result != tt.out {
+			t.Errorf("hasOptionEffect(%v, %v) => %v, want %v", tt.in, tt.effect, result, tt.out)
+		}
+	}
+}
+
+func TestCategorizeRPCWithoutSideEffects(t *testing.T) {
+	status := affs{
+		Name: "Status",
+		Req:  &wot.DataSchema{},
+		Res:  &wot.DataSchema{},
+		RPC:  rpcWithOption("Status", "idempotency_level", "NO_SIDE_EFFECTS"),
+	}
+	reset := affs{
+		Name: "Reset",
+		Req:  &wot.DataSchema{},
+		Res:  &wot.DataSchema{},
+		RPC:  rpcWithOption("Reset", "idempotency_level", "IDEMPOTENT"),
+	}
+	iab := newInteractionAffordanceBuilder(nil)
+	iab.affs = map[string]affs{"Status": status, "Reset": reset}
+	iab.categorizeRPCs()
+	iab.groupProperties()
+
+	equalsCombinedPropsSlice([]combinedProperties{{Name: "Status", GetProp: status, Category: 0}},
+		iab.affC.combinedProp, t)
+	equals([]affs{reset}, iab.affC.action, t)
+}
+
+func TestSaveActionWithOptions(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, nil)
+	b.saveAction(affs{
+		Name: "Reset",
+		Req:  &wot.DataSchema{},
+		Res:  &wot.DataSchema{},
+		RPC: &proto.RPC{
+			Name: "Reset",
+			Elements: []proto.Visitee{
+				&proto.Option{Name: "idempotency_level", Constant: proto.Literal{Source: "IDEMPOTENT"}},
+				&proto.Option{Name: "deprecated", Constant: proto.Literal{Source: "true"}},
+			},
+		},
+	})
+	a := b.td.Actions["Reset"]
+	if a.Safe || !a.Idempotent {
+		t.Errorf("Expected the action to be idempotent but not safe, but got safe: %v, idempotent: %v",
+			a.Safe, a.Idempotent)
+	}
+	if a.Description != "Deprecated" {
+		t.Errorf("Expected the action to be annotated as deprecated, but got the description %q", a.Description)
+	}
+}
+
+func aggregateOption(name string, values map[string]string) *proto.Option {
+	o := &proto.Option{Name: name}
+	for k, v := range values {
+		o.Constant.OrderedMap = append(o.Constant.OrderedMap,
+			&proto.NamedLiteral{Name: k, Literal: &proto.Literal{Source: v}})
+	}
+	return o
+}
+
+var annotatedClassConfigTest = []struct {
+	in  *proto.RPC
+	out affClassConfig
+	ok  bool
+}{
+	{
+		&proto.RPC{Name: "ReadMode", Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"kind": "PROPERTY", "name": "Mode"}),
+		}},
+		affClassConfig{AffClass: "property", Name: "Mode"},
+		true,
+	},
+	{
+		&proto.RPC{Name: "GetMode", Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"kind": "PROPERTY"}),
+		}},
+		affClassConfig{AffClass: "property", Name: "Mode"},
+		true,
+	},
+	{
+		&proto.RPC{Name: "Reset", Elements: []proto.Visitee{
+			aggregateOption("(.wot.affordance)", map[string]string{"kind": "ACTION"}),
+		}},
+		affClassConfig{AffClass: "action"},
+		true,
+	},
+	{
+		&proto.RPC{Name: "GetMode", Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"observable": "true"}),
+		}},
+		affClassConfig{},
+		false,
+	},
+	{
+		&proto.RPC{Name: "GetMode"},
+		affClassConfig{},
+		false,
+	},
+}
+
+func TestAnnotatedClassConfig(t *testing.T) {
+	for _, tt := range annotatedClassConfigTest {
+		result, ok := annotatedClassConfig(tt.in)
+		if ok != tt.ok || result.AffClass != tt.out.AffClass || result.Name != tt.out.Name {
+			t.Errorf("annotatedClassConfig(%v) => %v, %v, want %v, %v", tt.in.Name, result, ok, tt.out, tt.ok)
+		}
+	}
+}
+
+func TestAffordanceAnnotationReadOnly(t *testing.T) {
+	directive := &proto.Comment{Lines: []string{" @wot:property name=Mode readOnly"}}
+	for _, tt := range []struct {
+		in  *proto.RPC
+		out bool
+	}{
+		{&proto.RPC{Name: "GetMode", Comment: directive}, true},
+		{&proto.RPC{Name: "GetMode", Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"kind": "PROPERTY", "read_only": "true"}),
+		}}, true},
+		{&proto.RPC{Name: "GetMode", Comment: directive, Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"read_only": "false"}),
+		}}, false},
+		{&proto.RPC{Name: "GetMode", Comment: directive, Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"name": "Mode"}),
+		}}, true},
+	} {
+		if a, _ := affordanceAnnotation(tt.in); a.readOnly != tt.out {
+			t.Errorf("affordanceAnnotation(%v) => readOnly %v, want %v", tt.in.Elements, a.readOnly, tt.out)
+		}
+	}
+}
+
+func TestAffordanceAnnotationObservable(t *testing.T) {
+	directive := &proto.Comment{Lines: []string{" @wot:property name=Mode observable"}}
+	for _, tt := range []struct {
+		in  *proto.RPC
+		out bool
+	}{
+		{&proto.RPC{Name: "GetMode", Comment: directive}, true},
+		{&proto.RPC{Name: "GetMode", Comment: directive, Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"observable": "false"}),
+		}}, false},
+		{&proto.RPC{Name: "GetMode", Comment: directive, Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"name": "Mode"}),
+		}}, true},
+	} {
+		if a, _ := affordanceAnnotation(tt.in); a.observable != tt.out {
+			t.Errorf("affordanceAnnotation(%v) => observable %v, want %v", tt.in.Elements, a.observable, tt.out)
+		}
+	}
+}
+
+func TestApplyFieldOption(t *testing.T) {
+	ds := wot.DataSchema{DataType: "number"}
+	applyFieldOption(&ds, &proto.Field{
+		Name: "temperature",
+		Type: "double",
+		Options: []*proto.Option{
+			aggregateOption("(wot.field)", map[string]string{"unit": "om:degreeCelsius", "minimum": "-40"}),
+		},
+	})
+	if ds.Unit != "om:degreeCelsius" {
+		t.Errorf("Expected the unit om:degreeCelsius, but got %v", ds.Unit)
+	}
+	if ds.NumberSchema == nil || ds.Minimum == nil || *ds.Minimum != -40.0 {
+		t.Errorf("Expected the minimum -40, but got %v", ds.NumberSchema)
+	}
+	if ds.Maximum != nil {
+		t.Errorf("Expected no maximum, but got %v", *ds.Maximum)
+	}
+}
+
+func TestCategorizeAnnotatedRPCs(t *testing.T) {
+	readMode := affs{
+		Name: "ReadMode",
+		Req:  &wot.DataSchema{},
+		Res:  &wot.DataSchema{},
+		RPC: &proto.RPC{Name: "ReadMode", Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"kind": "PROPERTY", "name": "Mode"}),
+		}},
+	}
+	getStatus := affs{
+		Name: "GetStatus",
+		Req:  &wot.DataSchema{},
+		Res:  &wot.DataSchema{},
+		RPC: &proto.RPC{Name: "GetStatus", Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"kind": "EVENT"}),
+		}},
+	}
+	iab := newInteractionAffordanceBuilder(nil)
+	iab.affs = map[string]affs{"ReadMode": readMode, "GetStatus": getStatus}
+	iab.categorizeRPCs()
+	iab.groupProperties()
+	err := iab.categorizeAnnotatedRPCs()
+
+	errorCheck(t, nil, err)
+	equalsCombinedPropsSlice([]combinedProperties{{Name: "Mode", GetProp: readMode, Category: 0}},
+		iab.affC.combinedProp, t)
+	equals([]affs{getStatus}, iab.affC.event, t)
+	equals([]affs{}, iab.affC.action, t)
+}
+
+func TestCategorizeAnnotatedGetterAndSetter(t *testing.T) {
+	annotated := func(name string, req, res *wot.DataSchema) affs {
+		return affs{Name: name, Req: req, Res: res, RPC: &proto.RPC{Name: name, Elements: []proto.Visitee{
+			aggregateOption("(wot.affordance)", map[string]string{"kind": "PROPERTY"}),
+		}}}
+	}
+	level := &wot.DataSchema{DataType: "object"}
+	getLevel := annotated("GetLevel", nil, level)
+	setLevel := annotated("SetLevel", level, nil)
+	iab := newInteractionAffordanceBuilder(nil)
+	iab.affs = map[string]affs{"GetLevel": getLevel, "SetLevel": setLevel}
+	iab.rpcs = []*proto.RPC{getLevel.RPC, setLevel.RPC}
+	iab.categorizeRPCs()
+	iab.groupProperties()
+	err := iab.categorizeAnnotatedRPCs()
+
+	errorCheck(t, nil, err)
+	equalsCombinedPropsSlice([]combinedProperties{{Name: "Level", GetProp: getLevel, SetProp: setLevel,
+		Category: getPropertyCategory("GetLevel", "SetLevel")}}, iab.affC.combinedProp, t)
+}
@@ -0,0 +1,384 @@
+// Package consumer consumes Things described by the generated Thing Descriptions. The interactions are translated
+// into calls of the gRPC service with dynamic messages, so no generated stubs are needed
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/payload"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// contentTypeGrpc is the content type of the forms which are consumed with gRPC
+const contentTypeGrpc = "application/grpc+proto"
+
+// ConsumedThing invokes the RPCs of a gRPC service through the interaction affordances of its TD
+type ConsumedThing struct {
+	td    wot.ThingDescription
+	files []*desc.FileDescriptor
+	stub  grpcdynamic.Stub
+	rpcs  map[string]string // RPC name per operation on an affordance, e.g. readproperty/Brightness
+}
+
+// Option configures a ConsumedThing
+type Option func(*ConsumedThing) error
+
+// WithClassificationConfig resolves the RPCs of the affordances with the classification config written by prototd,
+// which is needed if the names of the properties do not follow the Get/Set convention of their RPCs or actions and
+// events are not named by their RPCs. The config records the operation each RPC serves on its property
+func WithClassificationConfig(file string) Option {
+	return func(c *ConsumedThing) error {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var config map[string]struct {
+			AffClass string
+			Name     string
+			Op       string
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return err
+		}
+		for rpc, v := range config {
+			name := v.Name
+			if name == "" {
+				name = rpc
+			}
+			switch v.AffClass {
+			case "action":
+				c.rpcs["invokeaction/"+name] = rpc
+				continue
+			case "event":
+				c.rpcs["subscribeevent/"+name] = rpc
+				continue
+			case "property":
+			default:
+				continue
+			}
+			if v.Op != "readproperty" && v.Op != "writeproperty" {
+				return fmt.Errorf("the classification config %s records no operation of the property RPC %s, "+
+					"regenerate it with prototd", file, rpc)
+			}
+			c.rpcs[v.Op+"/"+name] = rpc
+		}
+		return nil
+	}
+}
+
+// New creates a ConsumedThing for the TD, the RPCs are looked up in the file descriptors of the proto files
+func New(td wot.ThingDescription, files []*desc.FileDescriptor, conn grpc.ClientConnInterface, opts ...Option) (*ConsumedThing, error) {
+	c := &ConsumedThing{
+		td:    td,
+		files: files,
+		stub:  grpcdynamic.NewStub(conn),
+		rpcs:  map[string]string{},
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Dial connects to the gRPC service at the base of the TD and creates a ConsumedThing for it.
+// Without dial options, TLS is used if the base is https
+func Dial(td wot.ThingDescription, files []*desc.FileDescriptor, dialOpts []grpc.DialOption, opts ...Option) (*ConsumedThing, *grpc.ClientConn, error) {
+	base, err := url.Parse(td.Base)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dialOpts) == 0 {
+		creds := insecure.NewCredentials()
+		if base.Scheme == "https" {
+			creds = credentials.NewTLS(nil)
+		}
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	}
+	conn, err := grpc.Dial(base.Host, dialOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := New(td, files, conn, opts...)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return c, conn, nil
+}
+
+// ThingDescription returns the TD of the Thing
+func (c *ConsumedThing) ThingDescription() wot.ThingDescription {
+	return c.td
+}
+
+// grpcForm returns the gRPC form of the operation on the affordance
+func grpcForm(forms []wot.Form, op string) (wot.Form, bool) {
+	for _, f := range forms {
+		if f.ContentType != contentTypeGrpc {
+			continue
+		}
+		switch v := f.Op.(type) {
+		case string:
+			if v == op {
+				return f, true
+			}
+		case []string:
+			for _, o := range v {
+				if o == op {
+					return f, true
+				}
+			}
+		case []interface{}:
+			for _, o := range v {
+				if o == op {
+					return f, true
+				}
+			}
+		}
+	}
+	return wot.Form{}, false
+}
+
+// findService returns the service of the target IRI of a form, i.e. <Service>/<Affordance>
+func (c *ConsumedThing) findService(name string) (*desc.ServiceDescriptor, error) {
+	for _, fd := range c.files {
+		for _, s := range fd.GetServices() {
+			if s.GetName() == name || s.GetFullyQualifiedName() == name {
+				return s, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("service %s not found in the proto files", name)
+}
+
+// method resolves the RPC of the operation from the target IRI of the form. Actions and events are named by their
+// RPC, properties by the RPC without the Get/Set prefix, unless the classification config defines their RPCs
+func (c *ConsumedThing) method(forms []wot.Form, op string) (*desc.MethodDescriptor, error) {
+	f, ok := grpcForm(forms, op)
+	if !ok {
+		return nil, fmt.Errorf("no gRPC form for the operation %s", op)
+	}
+	parts := strings.SplitN(strings.SplitN(f.Href, "{", 2)[0], "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("the target IRI %s is no gRPC method", f.Href)
+	}
+	s, err := c.findService(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	name := parts[1]
+	var candidates []string
+	switch op {
+	case "readproperty":
+		candidates = []string{c.rpcs[op+"/"+name], "Get" + name, name}
+	case "writeproperty":
+		candidates = []string{c.rpcs[op+"/"+name], "Set" + name, name}
+	default:
+		candidates = []string{c.rpcs[op+"/"+name], name}
+	}
+	for _, n := range candidates {
+		if n == "" {
+			continue
+		}
+		for _, m := range s.GetMethods() {
+			if strings.EqualFold(m.GetName(), n) {
+				return m, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no RPC found for the operation %s on %s", op, name)
+}
+
+// request creates the request message of the RPC from the JSON object
+func request(m *desc.MethodDescriptor, obj map[string]interface{}) (*dynamic.Message, error) {
+	req := dynamic.NewMessage(m.GetInputType())
+	if err := payload.JSONToMessage(req, obj); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ReadProperty reads the property, the URI variables of a parameterised property are passed as fields of the request
+func (c *ConsumedThing) ReadProperty(ctx context.Context, name string, uriVariables map[string]interface{}) (map[string]interface{}, error) {
+	p, ok := c.td.Properties[name]
+	if !ok {
+		return nil, fmt.Errorf("no property %s", name)
+	}
+	return c.invoke(ctx, p.Forms, "readproperty", uriVariables)
+}
+
+// WriteProperty writes the value of the property
+func (c *ConsumedThing) WriteProperty(ctx context.Context, name string, value map[string]interface{}) error {
+	p, ok := c.td.Properties[name]
+	if !ok {
+		return fmt.Errorf("no property %s", name)
+	}
+	_, err := c.invoke(ctx, p.Forms, "writeproperty", value)
+	return err
+}
+
+// InvokeAction invokes the action with the input and returns its output
+func (c *ConsumedThing) InvokeAction(ctx context.Context, name string, input map[string]interface{}) (map[string]interface{}, error) {
+	a, ok := c.td.Actions[name]
+	if !ok {
+		return nil, fmt.Errorf("no action %s", name)
+	}
+	return c.invoke(ctx, a.Forms, "invokeaction", input)
+}
+
+// invoke calls the unary RPC of the operation
+func (c *ConsumedThing) invoke(ctx context.Context, forms []wot.Form, op string, obj map[string]interface{}) (map[string]interface{}, error) {
+	m, err := c.method(forms, op)
+	if err != nil {
+		return nil, err
+	}
+	req, err := request(m, obj)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.stub.InvokeRpc(ctx, m, req)
+	if err != nil {
+		return nil, err
+	}
+	return payload.MessageToJSON(res)
+}
+
+// Subscription is a subscription to an event, which ends when the stream of the RPC ends or Stop is called
+type Subscription struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	err    error
+}
+
+// Done is closed when the subscription ended
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error which ended the subscription, it is nil if the stream ended regularly or was stopped
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stop cancels the subscription and waits until the listener is not called anymore
+func (s *Subscription) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// SubscribeEvent subscribes to the event with the data of the subscription, the listener is called for each event
+// instance
+func (c *ConsumedThing) SubscribeEvent(ctx context.Context, name string, subscription map[string]interface{}, listener func(data map[string]interface{})) (*Subscription, error) {
+	e, ok := c.td.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("no event %s", name)
+	}
+	m, err := c.method(e.Forms, "subscribeevent")
+	if err != nil {
+		return nil, err
+	}
+	if !m.IsServerStreaming() {
+		return nil, fmt.Errorf("the RPC %s of the event %s is not server streaming", m.GetName(), name)
+	}
+	req, err := request(m, subscription)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.stub.InvokeRpcServerStream(ctx, m, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s := &Subscription{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer cancel()
+		for {
+			res, err := stream.RecvMsg()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					s.mu.Lock()
+					s.err = err
+					s.mu.Unlock()
+				}
+				return
+			}
+			data, err := payload.MessageToJSON(res)
+			if err != nil {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+				return
+			}
+			listener(data)
+		}
+	}()
+	return s, nil
+}
+
+// ReadPropertyJSON reads the property with the URI variables given as JSON object and returns the value as JSON
+func (c *ConsumedThing) ReadPropertyJSON(ctx context.Context, name string, uriVariables []byte) ([]byte, error) {
+	obj, err := decode(uriVariables)
+	if err != nil {
+		return nil, err
+	}
+	value, err := c.ReadProperty(ctx, name, obj)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// WritePropertyJSON writes the value of the property given as JSON
+func (c *ConsumedThing) WritePropertyJSON(ctx context.Context, name string, value []byte) error {
+	obj, err := decode(value)
+	if err != nil {
+		return err
+	}
+	return c.WriteProperty(ctx, name, obj)
+}
+
+// InvokeActionJSON invokes the action with the input given as JSON and returns the output as JSON
+func (c *ConsumedThing) InvokeActionJSON(ctx context.Context, name string, input []byte) ([]byte, error) {
+	obj, err := decode(input)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.InvokeAction(ctx, name, obj)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(output)
+}
+
+// decode decodes a JSON object, an empty payload is an empty object
+func decode(data []byte) (map[string]interface{}, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	obj, err := payload.DecodeJSON(data)
+	if err != nil {
+		return nil, errors.New("the payload is no JSON object: " + err.Error())
+	}
+	return obj, nil
+}
@@ -0,0 +1,240 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// thermostatServer implements the Thermostat service of testdata/thermostat.proto with dynamic messages
+type thermostatServer struct {
+	fd     *desc.FileDescriptor
+	target float64
+}
+
+func (s *thermostatServer) message(name string) *dynamic.Message {
+	return dynamic.NewMessage(s.fd.FindMessage("acme.thermostat.v1." + name))
+}
+
+func (s *thermostatServer) unary(name string, f func(req *dynamic.Message) (*dynamic.Message, error)) grpc.MethodDesc {
+	md := s.fd.FindService("acme.thermostat.v1.Thermostat").FindMethodByName(name)
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := dynamic.NewMessage(md.GetInputType())
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return f(req)
+		},
+	}
+}
+
+func (s *thermostatServer) temperature(celsius float64) *dynamic.Message {
+	t := s.message("Temperature")
+	t.SetFieldByName("celsius", celsius)
+	return t
+}
+
+func (s *thermostatServer) serviceDesc() *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{
+		ServiceName: "acme.thermostat.v1.Thermostat",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			s.unary("GetTargetTemperature", func(_ *dynamic.Message) (*dynamic.Message, error) {
+				return s.temperature(s.target), nil
+			}),
+			s.unary("SetTargetTemperature", func(req *dynamic.Message) (*dynamic.Message, error) {
+				s.target = req.GetFieldByName("celsius").(float64)
+				return s.message("Empty"), nil
+			}),
+			s.unary("ReadCurrent", func(req *dynamic.Message) (*dynamic.Message, error) {
+				if req.GetFieldByName("room") != "living" {
+					return nil, status.Error(codes.NotFound, "unknown room")
+				}
+				return s.temperature(20.5), nil
+			}),
+			s.unary("Boost", func(req *dynamic.Message) (*dynamic.Message, error) {
+				res := s.message("BoostResponse")
+				res.SetFieldByName("boosted", req.GetFieldByName("duration_ms").(int64) > 0)
+				return res, nil
+			}),
+		},
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "WatchTemperature",
+			ServerStreams: true,
+			Handler: func(_ interface{}, ss grpc.ServerStream) error {
+				if err := ss.RecvMsg(s.message("Room")); err != nil {
+					return err
+				}
+				for _, c := range []float64{19, 19.5} {
+					if err := ss.SendMsg(s.temperature(c)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}},
+	}
+}
+
+// newTestThing starts the Thermostat service in-process and consumes it with the TD in testdata
+func newTestThing(t *testing.T, opts ...Option) (*ConsumedThing, *thermostatServer) {
+	files, err := LoadProtoFile("testdata/thermostat.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := &thermostatServer{fd: files[0], target: 21}
+	server := grpc.NewServer()
+	server.RegisterService(s.serviceDesc(), s)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	b, err := ioutil.ReadFile("testdata/td.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var td wot.ThingDescription
+	if err := json.Unmarshal(b, &td); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(td, files, conn, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, s
+}
+
+func TestWithClassificationConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+  "SettingsGet": {"AffClass": "property", "Name": "Settings", "Op": "readproperty"},
+  "StoreSettings": {"AffClass": "property", "Name": "Settings", "Op": "writeproperty"},
+  "Reboot": {"AffClass": "action"},
+  "switch_on": {"AffClass": "action", "Name": "switch-on"}
+}`
+	if err := ioutil.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &ConsumedThing{rpcs: map[string]string{}}
+	if err := WithClassificationConfig(file)(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.rpcs["readproperty/Settings"] != "SettingsGet" || c.rpcs["writeproperty/Settings"] != "StoreSettings" ||
+		c.rpcs["invokeaction/switch-on"] != "switch_on" || len(c.rpcs) != 4 {
+		t.Errorf("Expected the RPCs of Settings by their recorded operation and of the action switch-on, but got %v",
+			c.rpcs)
+	}
+
+	if err := ioutil.WriteFile(file, []byte(`{"SetMode": {"AffClass": "property", "Name": "Mode"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithClassificationConfig(file)(&ConsumedThing{rpcs: map[string]string{}}); err == nil {
+		t.Errorf("Expected an error for a property RPC without operation")
+	}
+}
+
+func TestProperties(t *testing.T) {
+	c, s := newTestThing(t, WithClassificationConfig("testdata/config.json"))
+	ctx := context.Background()
+
+	value, err := c.ReadProperty(ctx, "TargetTemperature", nil)
+	if err != nil || value["celsius"] != 21.0 {
+		t.Errorf("Expected the target temperature 21, but got %v %v", value, err)
+	}
+	if err := c.WritePropertyJSON(ctx, "TargetTemperature", []byte(`{"celsius": 22.5}`)); err != nil || s.target != 22.5 {
+		t.Errorf("Expected the target temperature to be written, but got %v %v", s.target, err)
+	}
+	b, err := c.ReadPropertyJSON(ctx, "CurrentTemperature", []byte(`{"room": "living"}`))
+	if err != nil || string(b) != `{"celsius":20.5}` {
+		t.Errorf("Expected the current temperature of the living room, but got %s %v", b, err)
+	}
+	if _, err := c.ReadProperty(ctx, "CurrentTemperature", map[string]interface{}{"room": "attic"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected the gRPC status NOT_FOUND, but got %v", err)
+	}
+	if _, err := c.ReadProperty(ctx, "Humidity", nil); err == nil {
+		t.Errorf("Expected an error for an unknown property")
+	}
+}
+
+func TestPropertyWithoutConfig(t *testing.T) {
+	c, _ := newTestThing(t)
+	if _, err := c.ReadProperty(context.Background(), "TargetTemperature", nil); err != nil {
+		t.Errorf("Expected the RPC to be resolved by the Get prefix, but got %v", err)
+	}
+	if _, err := c.ReadProperty(context.Background(), "CurrentTemperature", nil); err == nil {
+		t.Errorf("Expected an error for the RPC which can only be resolved with the classification config")
+	}
+}
+
+func TestInvokeAction(t *testing.T) {
+	c, _ := newTestThing(t)
+	output, err := c.InvokeActionJSON(context.Background(), "Boost", []byte(`{"duration_ms": 600000}`))
+	if err != nil || string(output) != `{"boosted":true}` {
+		t.Errorf("Expected the boost to be started, but got %s %v", output, err)
+	}
+	if _, err := c.InvokeAction(context.Background(), "Boost", map[string]interface{}{"duration": 1}); err == nil {
+		t.Errorf("Expected an error for an unknown field of the input")
+	}
+}
+
+func TestSubscribeEvent(t *testing.T) {
+	c, _ := newTestThing(t)
+	var data []float64
+	sub, err := c.SubscribeEvent(context.Background(), "WatchTemperature", map[string]interface{}{"room": "living"},
+		func(d map[string]interface{}) { data = append(data, d["celsius"].(float64)) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the subscription to end with the stream")
+	}
+	if sub.Err() != nil || len(data) != 2 || data[1] != 19.5 {
+		t.Errorf("Expected two event instances, but got %v %v", data, sub.Err())
+	}
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	files, err := LoadProtoFile("testdata/thermostat.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(desc.ToFileDescriptorSet(files...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "thermostat.pb")
+	if err := ioutil.WriteFile(file, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadDescriptorSet(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].FindService("acme.thermostat.v1.Thermostat") == nil {
+		t.Errorf("Expected the Thermostat service in the descriptor set, but got %v", loaded)
+	}
+}
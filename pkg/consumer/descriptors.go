@@ -0,0 +1,43 @@
+package consumer
+
+import (
+	"io/ioutil"
+	"sort"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/protofmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// LoadProtoFile parses the proto file and its imports, which are resolved against the import paths.
+// Without import paths, the imports are resolved against the directory of the proto file
+func LoadProtoFile(file string, importPaths ...string) ([]*desc.FileDescriptor, error) {
+	fd, err := protofmt.ParseProtoFile(file, importPaths...)
+	if err != nil {
+		return nil, err
+	}
+	return []*desc.FileDescriptor{fd}, nil
+}
+
+// LoadDescriptorSet reads a FileDescriptorSet, e.g. written by protoc --descriptor_set_out --include_imports
+func LoadDescriptorSet(file string) ([]*desc.FileDescriptor, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	files, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return nil, err
+	}
+	var result []*desc.FileDescriptor
+	for _, fd := range files {
+		result = append(result, fd)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GetName() < result[j].GetName() })
+	return result, nil
+}
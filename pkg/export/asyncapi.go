@@ -0,0 +1,111 @@
+package export
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// AsyncAPIVersion is the version of the AsyncAPI Specification of the exported documents
+const AsyncAPIVersion = "3.0.0"
+
+// AsyncAPIDocument describes the events served by the gateway, cf. https://www.asyncapi.com/docs/reference/specification/v3.0.0
+type AsyncAPIDocument struct {
+	AsyncAPI   string                    `json:"asyncapi"`
+	Info       Info                      `json:"info"`
+	Servers    map[string]AsyncServer    `json:"servers,omitempty"`
+	Channels   map[string]Channel        `json:"channels"`
+	Operations map[string]AsyncOperation `json:"operations"`
+}
+
+// AsyncServer is the address of the gateway for a protocol
+type AsyncServer struct {
+	Host        string `json:"host"`
+	Pathname    string `json:"pathname,omitempty"`
+	Protocol    string `json:"protocol"`
+	Description string `json:"description,omitempty"`
+}
+
+// Channel is the target IRI of an event
+type Channel struct {
+	Address     string             `json:"address"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Messages    map[string]Message `json:"messages"`
+}
+
+// Message is an event instance
+type Message struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Payload     Schema `json:"payload,omitempty"`
+}
+
+// AsyncOperation is the subscription of an event
+type AsyncOperation struct {
+	Action      string      `json:"action"`
+	Channel     Reference   `json:"channel"`
+	Summary     string      `json:"summary,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Messages    []Reference `json:"messages"`
+}
+
+// AsyncAPI derives the AsyncAPI document of the events which the gateway serves for the TD. The gateway streams the
+// event instances as Server-Sent Events and over a WebSocket, so the server URL of the gateway is described for both
+func AsyncAPI(td wot.ThingDescription, bindings []grpcwot.Binding, serverURL string) (AsyncAPIDocument, error) {
+	doc := AsyncAPIDocument{
+		AsyncAPI:   AsyncAPIVersion,
+		Info:       info(td),
+		Channels:   map[string]Channel{},
+		Operations: map[string]AsyncOperation{},
+	}
+	if serverURL != "" {
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			return doc, err
+		}
+		pathname := strings.TrimSuffix(u.Path, "/")
+		sse, ws := "http", "ws"
+		if u.Scheme == "https" {
+			sse, ws = "https", "wss"
+		}
+		doc.Servers = map[string]AsyncServer{
+			"sse":       {Host: u.Host, Pathname: pathname, Protocol: sse, Description: "Server-Sent Events of the gateway"},
+			"websocket": {Host: u.Host, Pathname: pathname, Protocol: ws, Description: "WebSockets of the gateway"},
+		}
+	}
+	index := indexBindings(bindings)
+	for _, n := range td.EventNames() {
+		e := td.Events[n]
+		for _, o := range operations(e.Forms, index) {
+			if o.op != "subscribeevent" {
+				continue
+			}
+			id := identifier(n)
+			message := Message{Name: n, ContentType: contentTypeJSON}
+			if e.Data != nil {
+				payload, err := schema(*e.Data)
+				if err != nil {
+					return doc, err
+				}
+				message.Payload = payload
+			}
+			doc.Channels[id] = Channel{
+				Address:     "/" + o.binding.Href,
+				Title:       e.Title,
+				Description: e.Description,
+				Messages:    map[string]Message{id: message},
+			}
+			doc.Operations["subscribe"+id] = AsyncOperation{
+				Action:      "receive",
+				Channel:     Reference{"#/channels/" + id},
+				Summary:     e.Title,
+				Description: e.Description,
+				Messages:    []Reference{{"#/channels/" + id + "/messages/" + id}},
+			}
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,146 @@
+// Package export describes the HTTP surface of the gateway for tools which do not consume Thing Descriptions:
+// the properties and actions are exported as OpenAPI document and the events as AsyncAPI document. Both are derived
+// from the TD and the bindings of its operations, i.e. the classified affordances and their data schemas
+package export
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// contentTypeGrpc is the content type of the forms bound to the RPCs, the gateway serves their operations
+const contentTypeGrpc = "application/grpc+proto"
+
+// contentTypeJSON is the content type of the payloads of the gateway
+const contentTypeJSON = "application/json"
+
+// defaultVersion is the version of the API if the TD has no version
+const defaultVersion = "1.0.0"
+
+// Info holds the metadata of the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema is a JSON Schema, the data schemas of the TD are a subset of JSON Schema
+type Schema map[string]interface{}
+
+// Reference refers to a definition in the document
+type Reference struct {
+	Ref string `json:"$ref"`
+}
+
+// info returns the metadata of the API described by the TD
+func info(td wot.ThingDescription) Info {
+	i := Info{Title: td.Title, Version: defaultVersion, Description: td.Description}
+	if td.Version != nil && td.Version.Instance != "" {
+		i.Version = td.Version.Instance
+	}
+	return i
+}
+
+// tdTerms are the terms of the data schemas of the TD which are no JSON Schema keywords
+var tdTerms = []string{"@type", "titles", "descriptions"}
+
+// schema converts a data schema of the TD into a JSON Schema
+func schema(ds wot.DataSchema) (Schema, error) {
+	b, err := json.Marshal(ds)
+	if err != nil {
+		return nil, err
+	}
+	s := Schema{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	removeTDTerms(s)
+	return s, nil
+}
+
+// removeTDTerms removes the terms of the TD from the schema and its subschemas
+func removeTDTerms(s map[string]interface{}) {
+	for _, t := range tdTerms {
+		delete(s, t)
+	}
+	if properties, ok := s["properties"].(map[string]interface{}); ok {
+		for _, v := range properties {
+			if p, ok := v.(map[string]interface{}); ok {
+				removeTDTerms(p)
+			}
+		}
+	}
+	subschemas, _ := s["oneOf"].([]interface{})
+	switch items := s["items"].(type) {
+	case map[string]interface{}:
+		removeTDTerms(items)
+	case []interface{}:
+		subschemas = append(subschemas, items...)
+	}
+	for _, v := range subschemas {
+		if sub, ok := v.(map[string]interface{}); ok {
+			removeTDTerms(sub)
+		}
+	}
+}
+
+// operation is an operation on an affordance served by the gateway
+type operation struct {
+	op      string
+	binding grpcwot.Binding
+	form    wot.Form
+}
+
+// bindingKey identifies the binding of the operation at the target IRI
+func bindingKey(op, href string) string {
+	return op + " " + href
+}
+
+// indexBindings indexes the bindings by their operation and target IRI
+func indexBindings(bindings []grpcwot.Binding) map[string]grpcwot.Binding {
+	index := map[string]grpcwot.Binding{}
+	for _, b := range bindings {
+		index[bindingKey(b.Op, b.Href)] = b
+	}
+	return index
+}
+
+// operations returns the bound operations of the gRPC forms, like the gateway the URI template of a target IRI is
+// not part of the binding
+func operations(forms []wot.Form, index map[string]grpcwot.Binding) []operation {
+	var ops []operation
+	for _, f := range forms {
+		if f.ContentType != contentTypeGrpc {
+			continue
+		}
+		href := strings.SplitN(f.Href, "{", 2)[0]
+		for _, op := range f.Ops() {
+			if b, ok := index[bindingKey(op, href)]; ok {
+				ops = append(ops, operation{op, b, f})
+			}
+		}
+	}
+	return ops
+}
+
+// securityNames returns the names of the security definitions of a TD or form security
+func securityNames(security interface{}) []string {
+	switch v := security.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var names []string
+		for _, s := range v {
+			if n, ok := s.(string); ok {
+				names = append(names, n)
+			}
+		}
+		return names
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+func generate(t *testing.T) (wot.ThingDescription, []grpcwot.Binding) {
+	td, bindings, err := grpcwot.GenerateBindings("testdata/printer.proto", "", "127.0.0.1", 50051,
+		grpcwot.WithSecurityConfig("testdata/security.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return td, bindings
+}
+
+func TestOpenAPI(t *testing.T) {
+	td, bindings := generate(t)
+	doc, err := OpenAPI(td, bindings, "http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != OpenAPIVersion || doc.Info.Title != "Printer" || doc.Info.Version != "v1" {
+		t.Errorf("Expected the info of the Printer v1, but got %v %v", doc.OpenAPI, doc.Info)
+	}
+	var paths []string
+	for k, v := range doc.Paths {
+		for m := range v {
+			paths = append(paths, m+" "+k)
+		}
+	}
+	if len(paths) != 3 || doc.Paths["/Printer/Queue"]["get"] == nil || doc.Paths["/Printer/Queue/observe"]["get"] == nil ||
+		doc.Paths["/Printer/Print"]["post"] == nil {
+		t.Errorf("Expected to read and observe Queue and to invoke Print without events, but got %v", paths)
+	}
+
+	read := doc.Paths["/Printer/Queue"]["get"]
+	if read.OperationID != "readQueue" || len(read.Parameters) != 1 || read.Parameters[0].Name != "tray" ||
+		read.Parameters[0].In != "query" {
+		t.Errorf("Expected readQueue with the URI variable tray as query parameter, but got %+v", read)
+	}
+	s := read.Responses["200"].Content[contentTypeJSON].Schema
+	if properties, _ := s["properties"].(map[string]interface{}); properties["jobs"] == nil {
+		t.Errorf("Expected the schema of Queue, but got %v", s)
+	}
+	if _, ok := doc.Paths["/Printer/Queue/observe"]["get"].Responses["204"]; !ok {
+		t.Errorf("Expected a 204 response of the long-poll observation")
+	}
+
+	invoke := doc.Paths["/Printer/Print"]["post"]
+	if invoke.OperationID != "invokePrint" || invoke.Summary != "Print a job" || invoke.RequestBody == nil {
+		t.Errorf("Expected invokePrint with the input of Print, but got %+v", invoke)
+	}
+	badRequest := invoke.Responses["400"].Content[contentTypeJSON].Schema
+	if want := []interface{}{
+		Schema{"$ref": "#/components/schemas/FAILED_PRECONDITION.PreconditionFailure"},
+		Schema{"$ref": "#/components/schemas/INVALID_ARGUMENT"},
+	}; !reflect.DeepEqual(badRequest["oneOf"], want) {
+		t.Errorf("Expected the errors mapped to 400 as alternatives, but got %v", badRequest)
+	}
+	if s := invoke.Responses["429"].Content[contentTypeJSON].Schema; s["$ref"] != "#/components/schemas/RESOURCE_EXHAUSTED" {
+		t.Errorf("Expected RESOURCE_EXHAUSTED as 429, but got %v", s)
+	}
+	if _, ok := doc.Components.Schemas["FAILED_PRECONDITION.PreconditionFailure"]; !ok {
+		t.Errorf("Expected the schemas of the errors in the components, but got %v", doc.Components.Schemas)
+	}
+
+	if !reflect.DeepEqual(doc.Security, []SecurityRequirement{{"bearer_sc": {}}}) {
+		t.Errorf("Expected bearer_sc as security of the document, but got %v", doc.Security)
+	}
+	if !reflect.DeepEqual(invoke.Security, []SecurityRequirement{{"basic_sc": {}}}) || read.Security != nil {
+		t.Errorf("Expected basic_sc as security of Print only, but got %v and %v", invoke.Security, read.Security)
+	}
+	if s := doc.Components.SecuritySchemes["bearer_sc"]; s.Type != "http" || s.Scheme != "bearer" || s.BearerFormat != "jwt" {
+		t.Errorf("Expected bearer_sc as HTTP bearer scheme, but got %+v", s)
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAsyncAPI(t *testing.T) {
+	td, bindings := generate(t)
+	doc, err := AsyncAPI(td, bindings, "https://printer.example.com/api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.AsyncAPI != AsyncAPIVersion || doc.Info.Title != "Printer" {
+		t.Errorf("Expected the info of the Printer, but got %v %v", doc.AsyncAPI, doc.Info)
+	}
+	if ws := doc.Servers["websocket"]; ws.Host != "printer.example.com" || ws.Pathname != "/api" || ws.Protocol != "wss" {
+		t.Errorf("Expected the WebSocket server of the gateway, but got %+v", ws)
+	}
+	if sse := doc.Servers["sse"]; sse.Protocol != "https" {
+		t.Errorf("Expected the Server-Sent Events server of the gateway, but got %+v", sse)
+	}
+	if len(doc.Channels) != 1 {
+		t.Fatalf("Expected a channel for the event WatchJobs only, but got %v", doc.Channels)
+	}
+	c := doc.Channels["WatchJobs"]
+	if c.Address != "/Printer/WatchJobs" || c.Messages["WatchJobs"].Payload["type"] != "object" {
+		t.Errorf("Expected the channel of WatchJobs with the JobStatus payload, but got %+v", c)
+	}
+	op := doc.Operations["subscribeWatchJobs"]
+	if op.Action != "receive" || op.Channel.Ref != "#/channels/WatchJobs" ||
+		!reflect.DeepEqual(op.Messages, []Reference{{"#/channels/WatchJobs/messages/WatchJobs"}}) {
+		t.Errorf("Expected to receive the messages of WatchJobs, but got %+v", op)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s, err := schema(wot.DataSchema{
+		Type:   "saref:Temperature",
+		Titles: map[string]string{"de": "Temperatur"},
+		ArraySchema: &wot.ArraySchema{
+			Items: &wot.DataSchema{
+				DataType: "object",
+				ObjectSchema: &wot.ObjectSchema{Properties: map[string]wot.DataSchema{
+					"@type": {DataType: "string", Descriptions: map[string]string{"de": "Typ"}},
+				}},
+			},
+		},
+		DataType: "array",
+		Unit:     "om:degreeCelsius",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Schema{
+		"type": "array",
+		"unit": "om:degreeCelsius",
+		"items": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"@type": map[string]interface{}{"type": "string"}},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Expected the JSON Schema without the terms of the TD %v, but got %v", want, s)
+	}
+}
@@ -0,0 +1,395 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/gateway"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"google.golang.org/grpc/codes"
+)
+
+// OpenAPIVersion is the version of the OpenAPI Specification of the exported documents
+const OpenAPIVersion = "3.1.0"
+
+// statusSchemaName is the name of the schema of the errors returned by the gateway
+const statusSchemaName = "Status"
+
+// OpenAPIDocument describes the properties and actions served by the gateway,
+// cf. https://spec.openapis.org/oas/v3.1.0
+type OpenAPIDocument struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Server is the address of the gateway
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a target IRI by their lower-case HTTP method
+type PathItem map[string]*Operation
+
+// Operation is an operation on an affordance
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is a query parameter of an operation, i.e. a URI variable of the affordance
+type Parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required,omitempty"`
+	Schema   Schema `json:"schema"`
+}
+
+// RequestBody is the payload of a request
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the payload in a content type
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Components holds the schemas of the errors and the security schemes
+type Components struct {
+	Schemas         map[string]Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a security scheme of the gateway
+type SecurityScheme struct {
+	Type         string               `json:"type"`
+	Description  string               `json:"description,omitempty"`
+	Name         string               `json:"name,omitempty"`
+	In           string               `json:"in,omitempty"`
+	Scheme       string               `json:"scheme,omitempty"`
+	BearerFormat string               `json:"bearerFormat,omitempty"`
+	Flows        map[string]OAuthFlow `json:"flows,omitempty"`
+}
+
+// OAuthFlow is an OAuth 2.0 flow
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// SecurityRequirement names the security schemes which are required together
+type SecurityRequirement map[string][]string
+
+// openAPIBuilder derives the OpenAPI document of a TD
+type openAPIBuilder struct {
+	td       wot.ThingDescription
+	bindings map[string]grpcwot.Binding
+	doc      OpenAPIDocument
+}
+
+// OpenAPI derives the OpenAPI document of the properties and actions which the gateway serves for the TD.
+// Properties are read with GET, written with PUT and observed with GET at the target IRI followed by
+// gateway.ObservePath, actions are invoked with POST. Events are described by AsyncAPI.
+// Like the gateway, the RPCs bound to properties and actions are expected to be unary
+func OpenAPI(td wot.ThingDescription, bindings []grpcwot.Binding, serverURL string) (OpenAPIDocument, error) {
+	b := openAPIBuilder{
+		td:       td,
+		bindings: indexBindings(bindings),
+		doc: OpenAPIDocument{
+			OpenAPI: OpenAPIVersion,
+			Info:    info(td),
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas: map[string]Schema{statusSchemaName: statusSchema()},
+			},
+		},
+	}
+	if serverURL != "" {
+		b.doc.Servers = []Server{{URL: serverURL, Description: "Gateway of the gRPC service"}}
+	}
+	if err := b.securitySchemes(); err != nil {
+		return b.doc, err
+	}
+	if r := b.securityRequirement(td.Security); len(r) != 0 {
+		b.doc.Security = []SecurityRequirement{r}
+	}
+	for _, n := range td.PropertyNames() {
+		if err := b.property(n, td.Properties[n]); err != nil {
+			return b.doc, err
+		}
+	}
+	for _, n := range td.ActionNames() {
+		if err := b.action(n, td.Actions[n]); err != nil {
+			return b.doc, err
+		}
+	}
+	return b.doc, nil
+}
+
+// statusSchema describes the google.rpc.Status returned by the gateway for failed operations
+func statusSchema() Schema {
+	return Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code":    Schema{"type": "integer"},
+			"message": Schema{"type": "string"},
+			"details": Schema{"type": "array", "items": Schema{"type": "object"}},
+		},
+	}
+}
+
+// property adds the operations of a property
+func (b *openAPIBuilder) property(name string, p wot.PropertyAffordance) error {
+	value, err := schema(p.DataSchema)
+	if err != nil {
+		return err
+	}
+	for _, o := range operations(p.Forms, b.bindings) {
+		op, err := b.operation(name, "properties", p.InteractionAffordance, o)
+		if err != nil {
+			return err
+		}
+		switch o.op {
+		case "readproperty":
+			op.OperationID = "read" + identifier(name)
+			op.Responses["200"] = jsonResponse("The value of the property", value)
+			b.add(o.binding.Href, http.MethodGet, op)
+
+			// the gateway observes every readable property
+			observe := *op
+			observe.OperationID = "observe" + identifier(name)
+			observe.Description = strings.TrimSpace(observe.Description + "\n\nReturns the next change of the value " +
+				"of the property. The changes are also pushed as Server-Sent Events or over a WebSocket at this path.")
+			observe.Responses = copyResponses(op.Responses)
+			observe.Responses["200"] = jsonResponse("The changed value of the property", value)
+			observe.Responses["204"] = Response{Description: "The property did not change within the timeout"}
+			b.add(o.binding.Href+gateway.ObservePath, http.MethodGet, &observe)
+		case "writeproperty":
+			op.OperationID = "write" + identifier(name)
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(value)}
+			op.Responses["204"] = Response{Description: "The property was written"}
+			b.add(o.binding.Href, http.MethodPut, op)
+		}
+	}
+	return nil
+}
+
+// action adds the operation to invoke an action
+func (b *openAPIBuilder) action(name string, a wot.ActionAffordance) error {
+	for _, o := range operations(a.Forms, b.bindings) {
+		if o.op != "invokeaction" {
+			continue
+		}
+		op, err := b.operation(name, "actions", a.InteractionAffordance, o)
+		if err != nil {
+			return err
+		}
+		op.OperationID = "invoke" + identifier(name)
+		if a.Input != nil {
+			input, err := schema(*a.Input)
+			if err != nil {
+				return err
+			}
+			op.RequestBody = &RequestBody{Content: jsonContent(input)}
+		}
+		op.Responses["200"] = Response{Description: "The action was invoked"}
+		if response := grpcwot.InvokeResponse(a); response != nil {
+			output, err := schema(*response)
+			if err != nil {
+				return err
+			}
+			op.Responses["200"] = jsonResponse("The output of the action", output)
+		}
+		b.add(o.binding.Href, http.MethodPost, op)
+	}
+	return nil
+}
+
+// operation creates the operation with the URI variables, error responses and security of the form
+func (b *openAPIBuilder) operation(name, tag string, a wot.InteractionAffordance, o operation) (*Operation, error) {
+	op := &Operation{
+		Summary:     a.Title,
+		Description: a.Description,
+		Tags:        []string{tag},
+		Responses: map[string]Response{
+			"default": jsonResponse("The gRPC status of the failed operation", Schema{"$ref": componentRef(statusSchemaName)}),
+		},
+	}
+	if op.Summary == "" {
+		op.Summary = name
+	}
+	for _, v := range o.binding.UriVariables {
+		s, err := schema(a.UriVariables[v])
+		if err != nil {
+			return nil, err
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: v, In: "query", Schema: s})
+	}
+	if err := b.errorResponses(op, o.form.AdditionalResponses); err != nil {
+		return nil, err
+	}
+	if o.form.Security != nil {
+		r := b.securityRequirement(o.form.Security)
+		if len(b.doc.Security) == 0 || !reflect.DeepEqual(r, b.doc.Security[0]) {
+			op.Security = []SecurityRequirement{r}
+		}
+	}
+	return op, nil
+}
+
+// errorResponses adds the errors of the form as responses with the HTTP status code the gateway maps them to,
+// errors with the same HTTP status code are alternatives of the response
+func (b *openAPIBuilder) errorResponses(op *Operation, responses []wot.AdditionalExpectedResponse) error {
+	refs := map[int][]interface{}{}
+	var statusCodes []int
+	for _, r := range responses {
+		if r.Success || r.Schema == "" {
+			continue
+		}
+		ds, ok := b.td.SchemaDefinitions[r.Schema]
+		if !ok {
+			return fmt.Errorf("the schema %s of the error response is not defined", r.Schema)
+		}
+		s, err := schema(ds)
+		if err != nil {
+			return err
+		}
+		b.doc.Components.Schemas[r.Schema] = s
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(strconv.Quote(strings.SplitN(r.Schema, ".", 2)[0]))); err != nil {
+			return fmt.Errorf("the error response %s is not named by a gRPC status code", r.Schema)
+		}
+		code := gateway.HTTPStatusCode(c)
+		if _, ok := refs[code]; !ok {
+			statusCodes = append(statusCodes, code)
+		}
+		refs[code] = append(refs[code], Schema{"$ref": componentRef(r.Schema)})
+	}
+	for _, code := range statusCodes {
+		s := refs[code][0].(Schema)
+		if len(refs[code]) > 1 {
+			s = Schema{"oneOf": refs[code]}
+		}
+		description := http.StatusText(code)
+		if description == "" {
+			description = "Error"
+		}
+		op.Responses[strconv.Itoa(code)] = jsonResponse(description, s)
+	}
+	return nil
+}
+
+// securitySchemes converts the security definitions of the TD, nosec is expressed by an empty security requirement
+func (b *openAPIBuilder) securitySchemes() error {
+	for _, n := range b.td.SecurityDefinitionNames() {
+		d := b.td.SecurityDefinitions[n]
+		s := SecurityScheme{Description: d.Description}
+		switch d.Scheme {
+		case "nosec":
+			continue
+		case "basic", "digest", "bearer":
+			s.Type, s.Scheme, s.BearerFormat = "http", d.Scheme, d.Format
+		case "apikey":
+			s.Type, s.Name, s.In = "apiKey", d.Name, d.In
+			if s.In == "" {
+				s.In = "query"
+			}
+		case "oauth2":
+			s.Type = "oauth2"
+			flow := OAuthFlow{TokenURL: d.Token, RefreshURL: d.Refresh, Scopes: map[string]string{}}
+			for _, scope := range securityNames(d.Scopes) {
+				flow.Scopes[scope] = ""
+			}
+			switch d.Flow {
+			case "code":
+				flow.AuthorizationURL = d.Authorization
+				s.Flows = map[string]OAuthFlow{"authorizationCode": flow}
+			case "client":
+				s.Flows = map[string]OAuthFlow{"clientCredentials": flow}
+			default:
+				return fmt.Errorf("the OAuth 2.0 flow %s of the security definition %s is not supported", d.Flow, n)
+			}
+		default:
+			return fmt.Errorf("the security scheme %s of the security definition %s is not supported by OpenAPI",
+				d.Scheme, n)
+		}
+		if b.doc.Components.SecuritySchemes == nil {
+			b.doc.Components.SecuritySchemes = map[string]SecurityScheme{}
+		}
+		b.doc.Components.SecuritySchemes[n] = s
+	}
+	return nil
+}
+
+// securityRequirement requires all named security definitions, except nosec
+func (b *openAPIBuilder) securityRequirement(security interface{}) SecurityRequirement {
+	r := SecurityRequirement{}
+	for _, n := range securityNames(security) {
+		if _, ok := b.doc.Components.SecuritySchemes[n]; ok {
+			r[n] = []string{}
+		}
+	}
+	return r
+}
+
+// add adds the operation at the target IRI
+func (b *openAPIBuilder) add(href, method string, op *Operation) {
+	path := "/" + href
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = PathItem{}
+	}
+	b.doc.Paths[path][strings.ToLower(method)] = op
+}
+
+// componentRef refers to a schema of the components
+func componentRef(name string) string {
+	return "#/components/schemas/" + name
+}
+
+// jsonContent describes a JSON payload
+func jsonContent(s Schema) map[string]MediaType {
+	return map[string]MediaType{contentTypeJSON: {Schema: s}}
+}
+
+// jsonResponse describes a response with a JSON payload
+func jsonResponse(description string, s Schema) Response {
+	return Response{Description: description, Content: jsonContent(s)}
+}
+
+// copyResponses copies the responses of an operation
+func copyResponses(responses map[string]Response) map[string]Response {
+	c := make(map[string]Response, len(responses))
+	for k, v := range responses {
+		c[k] = v
+	}
+	return c
+}
+
+// identifier converts the name of an affordance into a part of an operationId
+func identifier(name string) string {
+	return strings.Join(strings.Fields(name), "")
+}
@@ -0,0 +1,258 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/payload"
+	"github.com/gorilla/websocket"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Subprotocols of the subscribeevent forms of the gateway
+const (
+	subprotocolSSE       = "sse"
+	subprotocolWebSocket = "websocket"
+)
+
+// subscriberBuffer is the number of event instances buffered for a subscriber. Subscribers which do not keep up
+// with the upstream stream are disconnected, so a slow consumer does not hold back the others
+const subscriberBuffer = 16
+
+// subscriber receives the event instances of a stream
+type subscriber struct {
+	events chan []byte
+	err    *status.Status // reason why the stream ended, set before events is closed
+}
+
+// stream fans out the messages of one upstream server stream to the subscribers of an event
+type stream struct {
+	subscribers map[*subscriber]bool
+	cancel      context.CancelFunc
+	ready       chan struct{} // closed once the upstream stream is started
+	err         error         // error of starting the upstream stream, set before ready is closed
+}
+
+// streams holds the running upstream streams, subscriptions with the same request and credentials share a stream
+type streams struct {
+	mu      sync.Mutex
+	running map[string]*stream
+}
+
+// subscribe adds a subscriber to the stream of the key, the upstream stream is started for the first subscriber.
+// The stream is registered before it is started without holding the lock, so a slow upstream only delays the
+// subscribers of its own key
+func (g *Gateway) subscribe(key string, start func(ctx context.Context) (func() ([]byte, error), error)) (*subscriber, error) {
+	sub := &subscriber{events: make(chan []byte, subscriberBuffer)}
+	g.streams.mu.Lock()
+	if s, ok := g.streams.running[key]; ok {
+		s.subscribers[sub] = true
+		g.streams.mu.Unlock()
+		<-s.ready
+		if s.err != nil {
+			return nil, s.err
+		}
+		return sub, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &stream{subscribers: map[*subscriber]bool{sub: true}, cancel: cancel, ready: make(chan struct{})}
+	g.streams.running[key] = s
+	g.streams.mu.Unlock()
+
+	recv, err := start(ctx)
+	g.streams.mu.Lock()
+	defer g.streams.mu.Unlock()
+	defer close(s.ready)
+	if err != nil {
+		s.err = err
+		delete(g.streams.running, key)
+		cancel()
+		return nil, err
+	}
+	go g.fanOut(key, s, recv)
+	return sub, nil
+}
+
+// unsubscribe removes the subscriber, the upstream stream is cancelled when the last subscriber leaves
+func (g *Gateway) unsubscribe(key string, sub *subscriber) {
+	g.streams.mu.Lock()
+	defer g.streams.mu.Unlock()
+	s, ok := g.streams.running[key]
+	if !ok || !s.subscribers[sub] {
+		return
+	}
+	delete(s.subscribers, sub)
+	if len(s.subscribers) == 0 {
+		s.cancel()
+		delete(g.streams.running, key)
+	}
+}
+
+// fanOut receives the messages of the upstream stream and passes them to all subscribers until the stream ends
+func (g *Gateway) fanOut(key string, s *stream, recv func() ([]byte, error)) {
+	for {
+		event, err := recv()
+		g.streams.mu.Lock()
+		if err != nil {
+			if g.streams.running[key] == s {
+				delete(g.streams.running, key)
+			}
+			st := statusFromError(err)
+			for sub := range s.subscribers {
+				sub.err = st
+				close(sub.events)
+			}
+			s.subscribers = map[*subscriber]bool{}
+			g.streams.mu.Unlock()
+			s.cancel()
+			return
+		}
+		for sub := range s.subscribers {
+			select {
+			case sub.events <- event:
+			default:
+				sub.err = status.New(codes.ResourceExhausted, "the subscriber does not keep up with the event stream")
+				close(sub.events)
+				delete(s.subscribers, sub)
+			}
+		}
+		if len(s.subscribers) == 0 && g.streams.running[key] == s {
+			// all subscribers were too slow
+			delete(g.streams.running, key)
+			s.cancel()
+		}
+		g.streams.mu.Unlock()
+	}
+}
+
+// serveEvent subscribes to the event with a Server-Sent Events stream or a WebSocket, depending on the request
+func (g *Gateway) serveEvent(w http.ResponseWriter, r *http.Request, rt route) {
+	req := dynamic.NewMessage(rt.method.GetInputType())
+	if err := requestMessage(r, rt, req); err != nil {
+		g.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	credentials := g.credentials(r)
+	key := strings.Join(append([]string{rt.binding.Href, r.URL.RawQuery}, credentials...), "\x00")
+	sub, err := g.subscribe(key, func(ctx context.Context) (func() ([]byte, error), error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, credentials...)
+		ss, err := g.stub.InvokeRpcServerStream(ctx, rt.method, req)
+		if err != nil {
+			return nil, err
+		}
+		return func() ([]byte, error) {
+			res, err := ss.RecvMsg()
+			if err != nil {
+				return nil, err
+			}
+			obj, err := payload.MessageToJSON(res)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(obj)
+		}, nil
+	})
+	if err != nil {
+		g.writeStatus(w, statusFromError(err))
+		return
+	}
+	defer g.unsubscribe(key, sub)
+	if websocket.IsWebSocketUpgrade(r) {
+		serveWebSocket(w, r, sub)
+		return
+	}
+	g.serveSSE(w, r, sub)
+}
+
+// statusJSON serializes the status as google.rpc.Status
+func (g *Gateway) statusJSON(s *status.Status) []byte {
+	b, _ := json.Marshal(g.statusObject(s))
+	return b
+}
+
+// serveSSE writes the event instances as Server-Sent Events, the end of the stream is sent as error event
+func (g *Gateway) serveSSE(w http.ResponseWriter, r *http.Request, sub *subscriber) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		g.writeStatus(w, status.New(codes.Unimplemented, "streaming is not supported by the connection"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case event, ok := <-sub.events:
+			if !ok {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", g.statusJSON(sub.err))
+				flusher.Flush()
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", event)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// maxCloseReason is the maximum length of the reason of a close message
+const maxCloseReason = 123
+
+// closeReason returns the message of the status as reason of a close message
+func closeReason(s *status.Status) string {
+	reason := s.Code().String() + ": " + s.Message()
+	if len(reason) > maxCloseReason {
+		reason = reason[:maxCloseReason]
+	}
+	return reason
+}
+
+// upgrader accepts WebSocket connections of all origins, like the CORS settings of the server
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// serveWebSocket writes the event instances as text messages, the end of the stream closes the WebSocket with the
+// code and the message of the status as reason
+func serveWebSocket(w http.ResponseWriter, r *http.Request, sub *subscriber) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	closed := make(chan struct{})
+	go func() {
+		// the messages of the consumer are discarded, reading processes the close and ping messages
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case event, ok := <-sub.events:
+			if !ok {
+				code := websocket.CloseNormalClosure
+				if sub.err.Code() != codes.OK {
+					code = websocket.CloseInternalServerErr
+				}
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, closeReason(sub.err)))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
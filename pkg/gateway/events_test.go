@@ -0,0 +1,183 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+)
+
+// await fails the test if nothing is received on the channel within a second
+func await(t *testing.T, ch <-chan struct{}, msg string) {
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal(msg)
+	}
+}
+
+func TestEventFanOut(t *testing.T) {
+	ts, s, g := newTestGateway(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/Lamp/WatchBrightness", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected Server-Sent Events, but got %s", res.Header.Get("Content-Type"))
+	}
+	await(t, s.watchers, "Expected the upstream stream to be started")
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/Lamp/WatchBrightness", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	// wait until the WebSocket joined the running stream
+	for deadline := time.Now().Add(time.Second); ; {
+		g.streams.mu.Lock()
+		n := 0
+		for _, v := range g.streams.running {
+			n += len(v.subscribers)
+		}
+		g.streams.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected two subscribers of one stream, but got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.updates <- 70
+	sse := bufio.NewReader(res.Body)
+	line, err := sse.ReadString('\n')
+	if err != nil || line != "data: {\"level\":70,\"room\":\"kitchen\"}\n" {
+		t.Errorf("Expected the event instance as Server-Sent Event, but got %q %v", line, err)
+	}
+	_, msg, err := ws.ReadMessage()
+	if err != nil || string(msg) != "{\"level\":70,\"room\":\"kitchen\"}" {
+		t.Errorf("Expected the event instance as WebSocket message, but got %q %v", msg, err)
+	}
+	select {
+	case <-s.watchers:
+		t.Errorf("Expected the subscribers to share one upstream stream")
+	default:
+	}
+
+	cancel()
+	ws.Close()
+	await(t, s.cancelled, "Expected the upstream stream to be cancelled when the last subscriber left")
+	g.streams.mu.Lock()
+	defer g.streams.mu.Unlock()
+	if len(g.streams.running) != 0 {
+		t.Errorf("Expected no running streams, but got %v", g.streams.running)
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	g := &Gateway{streams: streams{running: map[string]*stream{}}}
+	cancelled := make(chan struct{})
+	sub, err := g.subscribe("key", func(ctx context.Context) (func() ([]byte, error), error) {
+		go func() {
+			<-ctx.Done()
+			close(cancelled)
+		}()
+		return func() ([]byte, error) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return []byte("{}"), nil
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	await(t, cancelled, "Expected the upstream stream to be cancelled without subscribers")
+	n := 0
+	for range sub.events {
+		n++
+	}
+	if n != subscriberBuffer || sub.err.Code() != codes.ResourceExhausted {
+		t.Errorf("Expected the slow subscriber to be disconnected after %d events, but got %d events and %v",
+			subscriberBuffer, n, sub.err)
+	}
+}
+
+func TestBlockingUpstream(t *testing.T) {
+	g := &Gateway{streams: streams{running: map[string]*stream{}}}
+	unblock, started := make(chan struct{}), make(chan struct{})
+	blocking := func(ctx context.Context) (func() ([]byte, error), error) {
+		close(started)
+		<-unblock
+		return nil, context.DeadlineExceeded
+	}
+	idle := func(ctx context.Context) (func() ([]byte, error), error) {
+		return func() ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}, nil
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := g.subscribe("slow", blocking)
+		errs <- err
+	}()
+	await(t, started, "Expected the upstream stream of slow to be started")
+	go func() {
+		_, err := g.subscribe("slow", blocking)
+		errs <- err
+	}()
+
+	subscribed := make(chan struct{})
+	go func() {
+		sub, err := g.subscribe("fast", idle)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		g.unsubscribe("fast", sub)
+		close(subscribed)
+	}()
+	await(t, subscribed, "Expected to subscribe to another key while an upstream stream is starting")
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		g.streams.mu.Lock()
+		waiting := len(g.streams.running["slow"].subscribers)
+		g.streams.mu.Unlock()
+		if waiting == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the second subscriber of slow to wait for the starting stream")
+		}
+	}
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err != context.DeadlineExceeded {
+				t.Errorf("Expected both subscribers of slow to get the error of the upstream, but got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the subscribers of slow to return")
+		}
+	}
+	if len(g.streams.running) != 0 {
+		t.Errorf("Expected no running streams, but got %v", g.streams.running)
+	}
+}
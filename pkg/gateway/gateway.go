@@ -0,0 +1,467 @@
+// Package gateway serves the interaction affordances of a Thing Description generated from a proto file
+// by translating the HTTP requests of WoT consumers into calls of the gRPC service
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/payload"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	// the standard details of errors of google.rpc are linked, so they are unpacked without being imported
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// WellKnownPath is the path where the gateway exposes its TD, cf. https://www.w3.org/TR/wot-discovery/#introduction-well-known
+const WellKnownPath = "/.well-known/wot"
+
+// contentTypeJSON is the content type of the payloads of the gateway
+const contentTypeJSON = "application/json"
+
+// maxRequestBytes limits the size of the payloads of requests
+const maxRequestBytes = 4 << 20
+
+// opMethods are the default HTTP methods of the operations according to the HTTP binding of WoT
+var opMethods = map[string]string{
+	"readproperty":   http.MethodGet,
+	"writeproperty":  http.MethodPut,
+	"invokeaction":   http.MethodPost,
+	"subscribeevent": http.MethodGet,
+}
+
+// route is an operation served by the gateway
+type route struct {
+	binding grpcwot.Binding
+	method  *desc.MethodDescriptor
+}
+
+// Gateway is a http.Handler which serves the TD and its affordances
+type Gateway struct {
+	td      wot.ThingDescription
+	stub    grpcdynamic.Stub
+	routes  map[string]map[string]route // target IRI -> HTTP method -> route
+	streams streams
+	headers []string               // headers holding the credentials of the security definitions
+	files   []*desc.FileDescriptor // the proto file and its imports, which define the details of errors
+}
+
+// New creates a gateway for the TD, the operations are served by the unary RPCs they are bound to and events by
+// server streaming RPCs. Other operations are not served and removed from the TD of the gateway
+func New(fd *desc.FileDescriptor, td wot.ThingDescription, bindings []grpcwot.Binding, conn grpc.ClientConnInterface) (*Gateway, error) {
+	g := &Gateway{
+		stub:    grpcdynamic.NewStub(conn),
+		routes:  map[string]map[string]route{},
+		streams: streams{running: map[string]*stream{}},
+		headers: credentialHeaders(td.SecurityDefinitions),
+		files:   importedFiles(fd, nil),
+	}
+	for _, b := range bindings {
+		method, ok := opMethods[b.Op]
+		if !ok {
+			continue
+		}
+		s := fd.FindService(b.Service)
+		if s == nil {
+			return nil, fmt.Errorf("service %s not found in %s", b.Service, fd.GetName())
+		}
+		md := s.FindMethodByName(b.RPC)
+		if md == nil {
+			return nil, fmt.Errorf("RPC %s not found in service %s", b.RPC, b.Service)
+		}
+		if md.IsClientStreaming() || md.IsServerStreaming() != (b.Op == "subscribeevent") {
+			continue
+		}
+		g.addRoute(b.Href, method, route{b, md})
+		if b.Op == "readproperty" {
+			// every readable property can be observed by polling its getter
+			observe := b
+			observe.Op = "observeproperty"
+			g.addRoute(b.Href+ObservePath, http.MethodGet, route{observe, md})
+		}
+	}
+	var err error
+	g.td, err = g.thingDescription(td)
+	return g, err
+}
+
+// addRoute serves the operation of the route at the target IRI with the HTTP method
+func (g *Gateway) addRoute(href, method string, rt route) {
+	if g.routes[href] == nil {
+		g.routes[href] = map[string]route{}
+	}
+	g.routes[href][method] = rt
+}
+
+// thingDescription derives the TD of the gateway, it only holds the forms served by the gateway.
+// The base is set for every request to the address the TD was requested from
+func (g *Gateway) thingDescription(td wot.ThingDescription) (wot.ThingDescription, error) {
+	c := wot.ThingDescription{}
+	b, err := json.Marshal(td)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, err
+	}
+	for k, v := range c.Properties {
+		v.Forms = g.forms(v.Forms)
+		if len(v.Forms) == 0 {
+			delete(c.Properties, k)
+			continue
+		}
+		for _, f := range v.Forms {
+			if ops, _ := f.Op.([]string); contains(ops, "observeproperty") {
+				v.Observable = true
+			}
+		}
+		c.Properties[k] = v
+	}
+	for k, v := range c.Actions {
+		v.Forms = g.forms(v.Forms)
+		if len(v.Forms) == 0 {
+			delete(c.Actions, k)
+			continue
+		}
+		// the gateway responds with the Operation started by an asynchronous action
+		v.Output = grpcwot.InvokeResponse(v)
+		c.Actions[k] = v
+	}
+	for k, v := range c.Events {
+		v.Forms = g.forms(v.Forms)
+		if len(v.Forms) == 0 {
+			delete(c.Events, k)
+			continue
+		}
+		c.Events[k] = v
+	}
+	return c, nil
+}
+
+// forms converts the gRPC forms of an affordance into the JSON forms of the gateway
+func (g *Gateway) forms(forms []wot.Form) []wot.Form {
+	var result []wot.Form
+	for _, f := range forms {
+		href := strings.SplitN(f.Href, "{", 2)[0]
+		ops, _ := f.Op.([]interface{})
+		for _, op := range ops {
+			method := opMethods[fmt.Sprint(op)]
+			if _, ok := g.routes[href][method]; !ok || f.ContentType != "application/grpc+proto" {
+				continue
+			}
+			c := f
+			c.Op = []string{fmt.Sprint(op)}
+			c.ContentType = contentTypeJSON
+			c.MethodName = method
+			if method != http.MethodGet {
+				c.Href = href
+			}
+			if op == "subscribeevent" {
+				// the event is served as Server-Sent Events and over a WebSocket, whose absolute target IRI
+				// is set when the TD is requested
+				c.MethodName = ""
+				ws := c
+				c.SubProtocol, ws.SubProtocol = subprotocolSSE, subprotocolWebSocket
+				result = append(result, c, ws)
+				continue
+			}
+			result = append(result, c)
+			if op == "readproperty" {
+				result = append(result, observeForms(c)...)
+			}
+		}
+	}
+	return result
+}
+
+// observeForms returns the forms to observe a property from the form to read it, the changes are pushed as
+// Server-Sent Events, over a WebSocket or returned to long-poll requests
+func observeForms(read wot.Form) []wot.Form {
+	var forms []wot.Form
+	for _, subprotocol := range []string{subprotocolSSE, subprotocolWebSocket, subprotocolLongPoll} {
+		f := read
+		f.Op = []string{"observeproperty"}
+		f.Href = observeHref(read.Href)
+		f.SubProtocol = subprotocol
+		if subprotocol != subprotocolLongPoll {
+			f.MethodName = ""
+		}
+		forms = append(forms, f)
+	}
+	return forms
+}
+
+// credentialHeaders returns the headers which hold credentials according to the security definitions of the TD,
+// e.g. the header of an apikey scheme. The Authorization header is always included
+func credentialHeaders(definitions map[string]wot.SecurityScheme) []string {
+	headers := []string{"authorization"}
+	for _, s := range definitions {
+		name := strings.ToLower(s.Name)
+		if (s.In == "" || s.In == "header") && name != "" && !contains(headers, name) {
+			headers = append(headers, name)
+		}
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// credentials returns the credentials of the request as key-value pairs of gRPC metadata
+func (g *Gateway) credentials(r *http.Request) []string {
+	var kv []string
+	for _, h := range g.headers {
+		for _, v := range r.Header.Values(h) {
+			kv = append(kv, h, v)
+		}
+	}
+	return kv
+}
+
+// contains determines if the slice contains the string s
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves the TD at WellKnownPath and the operations at the target IRIs of their forms
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	if r.URL.Path == WellKnownPath {
+		g.serveThingDescription(w, r)
+		return
+	}
+	methods, ok := g.routes[strings.TrimPrefix(r.URL.Path, "/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	rt, ok := methods[r.Method]
+	if !ok {
+		var allowed []string
+		for m := range methods {
+			allowed = append(allowed, m)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch rt.binding.Op {
+	case "subscribeevent":
+		g.serveEvent(w, r, rt)
+	case "observeproperty":
+		g.serveObservation(w, r, rt)
+	default:
+		g.serveOperation(w, r, rt)
+	}
+}
+
+// serveThingDescription writes the TD of the gateway with the address of the request as base
+func (g *Gateway) serveThingDescription(w http.ResponseWriter, r *http.Request) {
+	td := g.td
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	td.Base = fmt.Sprintf("%s://%s/", scheme, r.Host)
+	// the target IRIs of WebSockets are absolute, as their scheme differs from the base
+	wsForms := func(forms []wot.Form) []wot.Form {
+		result := make([]wot.Form, len(forms))
+		for i, f := range forms {
+			if f.SubProtocol == subprotocolWebSocket {
+				f.Href = strings.Replace(td.Base, "http", "ws", 1) + f.Href
+			}
+			result[i] = f
+		}
+		return result
+	}
+	td.Properties = map[string]wot.PropertyAffordance{}
+	for k, v := range g.td.Properties {
+		v.Forms = wsForms(v.Forms)
+		td.Properties[k] = v
+	}
+	td.Events = map[string]wot.EventAffordance{}
+	for k, v := range g.td.Events {
+		v.Forms = wsForms(v.Forms)
+		td.Events[k] = v
+	}
+	w.Header().Set("Content-Type", wot.MediaTypeThingDescription)
+	_ = json.NewEncoder(w).Encode(td)
+}
+
+// serveOperation invokes the RPC of the route with the request message built from the URI variables or the payload
+func (g *Gateway) serveOperation(w http.ResponseWriter, r *http.Request, rt route) {
+	req := dynamic.NewMessage(rt.method.GetInputType())
+	if err := requestMessage(r, rt, req); err != nil {
+		g.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	// credentials are passed on as gRPC metadata
+	ctx := metadata.AppendToOutgoingContext(r.Context(), g.credentials(r)...)
+	res, err := g.stub.InvokeRpc(ctx, rt.method, req)
+	if err != nil {
+		g.writeStatus(w, statusFromError(err))
+		return
+	}
+	if rt.binding.Op == "writeproperty" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	obj, err := payload.MessageToJSON(res)
+	if err != nil {
+		g.writeStatus(w, status.New(codes.Internal, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+// requestMessage sets the fields of the request message from the URI variables and the JSON payload
+func requestMessage(r *http.Request, rt route, req *dynamic.Message) error {
+	query := r.URL.Query()
+	for _, v := range rt.binding.UriVariables {
+		if value := query.Get(v); value != "" {
+			fd := req.GetMessageDescriptor().FindFieldByName(v)
+			if fd == nil {
+				return fmt.Errorf("unknown URI variable %s", v)
+			}
+			if err := payload.SetField(req, fd, value); err != nil {
+				return err
+			}
+		}
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(strings.TrimSpace(string(body))) == 0 {
+		return err
+	}
+	obj, err := payload.DecodeJSON(body)
+	if err != nil {
+		return err
+	}
+	return payload.JSONToMessage(req, obj)
+}
+
+// statusFromError returns the gRPC status of an error of a RPC, a cancelled request is reported as such and the end
+// of a stream as OK
+func statusFromError(err error) *status.Status {
+	switch err {
+	case context.Canceled:
+		return status.New(codes.Canceled, err.Error())
+	case io.EOF:
+		return status.New(codes.OK, "the stream ended")
+	}
+	return status.Convert(err)
+}
+
+// httpStatusCodes maps the gRPC status codes to HTTP status codes,
+// cf. https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+var httpStatusCodes = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatusCode returns the HTTP status code the gateway responds with for the gRPC status code
+func HTTPStatusCode(c codes.Code) int {
+	if code, ok := httpStatusCodes[c]; ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// writeStatus writes the gRPC status as google.rpc.Status, as described by the additionalResponses of the TD
+func (g *Gateway) writeStatus(w http.ResponseWriter, s *status.Status) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(HTTPStatusCode(s.Code()))
+	_ = json.NewEncoder(w).Encode(g.statusObject(s))
+}
+
+// statusObject returns the status as JSON object of google.rpc.Status. The details are unpacked with the messages of
+// the proto file, its imports and the standard details of google.rpc, and named by their type URL as @type
+func (g *Gateway) statusObject(s *status.Status) map[string]interface{} {
+	details := []interface{}{}
+	for _, a := range s.Proto().GetDetails() {
+		details = append(details, g.detailObject(a))
+	}
+	return map[string]interface{}{
+		"code":    int(s.Code()),
+		"message": s.Message(),
+		"details": details,
+	}
+}
+
+// detailObject unpacks a detail of a status, details of unknown messages keep their binary value base64 encoded
+func (g *Gateway) detailObject(a *anypb.Any) map[string]interface{} {
+	name := a.GetTypeUrl()[strings.LastIndex(a.GetTypeUrl(), "/")+1:]
+	if md := g.findMessage(name); md != nil {
+		m := dynamic.NewMessage(md)
+		if err := m.Unmarshal(a.GetValue()); err == nil {
+			if obj, err := payload.MessageToJSON(m); err == nil {
+				obj["@type"] = a.GetTypeUrl()
+				return obj
+			}
+		}
+	}
+	return map[string]interface{}{
+		"@type": a.GetTypeUrl(),
+		"value": base64.StdEncoding.EncodeToString(a.GetValue()),
+	}
+}
+
+// findMessage finds the message in the proto file and its imports or among the linked messages, e.g. of google.rpc
+func (g *Gateway) findMessage(name string) *desc.MessageDescriptor {
+	for _, fd := range g.files {
+		if md := fd.FindMessage(name); md != nil {
+			return md
+		}
+	}
+	md, _ := desc.LoadMessageDescriptor(name)
+	return md
+}
+
+// importedFiles returns the file and all files it imports
+func importedFiles(fd *desc.FileDescriptor, files []*desc.FileDescriptor) []*desc.FileDescriptor {
+	for _, f := range files {
+		if f == fd {
+			return files
+		}
+	}
+	files = append(files, fd)
+	for _, dep := range fd.GetDependencies() {
+		files = importedFiles(dep, files)
+	}
+	return files
+}
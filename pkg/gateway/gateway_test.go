@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/protofmt"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// lampServer implements the Lamp service of testdata/lamp.proto with dynamic messages
+type lampServer struct {
+	fd         *desc.FileDescriptor
+	brightness map[string]int32
+	auth       string
+	apiKey     string
+	updates    chan int32    // brightness levels sent to the subscribers of WatchBrightness
+	watchers   chan struct{} // signals that WatchBrightness was called
+	cancelled  chan struct{} // signals that the WatchBrightness stream was cancelled
+}
+
+// handler returns the handler of an unary RPC, which decodes the request as dynamic message
+func (s *lampServer) handler(name string, f func(req *dynamic.Message) (*dynamic.Message, error)) grpc.MethodDesc {
+	md := s.fd.FindService("acme.lamp.v1.Lamp").FindMethodByName(name)
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := dynamic.NewMessage(md.GetInputType())
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if m, ok := metadata.FromIncomingContext(ctx); ok {
+				if len(m["authorization"]) != 0 {
+					s.auth = m["authorization"][0]
+				}
+				if len(m["x-api-key"]) != 0 {
+					s.apiKey = m["x-api-key"][0]
+				}
+			}
+			return f(req)
+		},
+	}
+}
+
+func (s *lampServer) serviceDesc() *grpc.ServiceDesc {
+	message := func(name string) *dynamic.Message {
+		return dynamic.NewMessage(s.fd.FindMessage("acme.lamp.v1." + name))
+	}
+	return &grpc.ServiceDesc{
+		ServiceName: "acme.lamp.v1.Lamp",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			s.handler("GetBrightness", func(req *dynamic.Message) (*dynamic.Message, error) {
+				room := req.GetFieldByName("room").(string)
+				level, ok := s.brightness[room]
+				if !ok {
+					st, err := status.Newf(codes.NotFound, "no lamp in room %s", room).
+						WithDetails(&errdetails.ResourceInfo{ResourceType: "room", ResourceName: room})
+					if err != nil {
+						return nil, err
+					}
+					return nil, st.Err()
+				}
+				res := message("Brightness")
+				res.SetFieldByName("room", room)
+				res.SetFieldByName("level", level)
+				return res, nil
+			}),
+			s.handler("SetBrightness", func(req *dynamic.Message) (*dynamic.Message, error) {
+				s.brightness[req.GetFieldByName("room").(string)] = req.GetFieldByName("level").(int32)
+				return message("Empty"), nil
+			}),
+			s.handler("Toggle", func(req *dynamic.Message) (*dynamic.Message, error) {
+				if !req.HasFieldName("room") {
+					return nil, status.Error(codes.InvalidArgument, "room required")
+				}
+				res := message("ToggleResponse")
+				res.SetFieldByName("on", true)
+				res.SetFieldByName("switched_at", int64(1666166400000))
+				return res, nil
+			}),
+		},
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "WatchBrightness",
+			ServerStreams: true,
+			Handler: func(_ interface{}, ss grpc.ServerStream) error {
+				if err := ss.RecvMsg(message("Empty")); err != nil {
+					return err
+				}
+				s.watchers <- struct{}{}
+				for {
+					select {
+					case level := <-s.updates:
+						res := message("Brightness")
+						res.SetFieldByName("room", "kitchen")
+						res.SetFieldByName("level", level)
+						if err := ss.SendMsg(res); err != nil {
+							return err
+						}
+					case <-ss.Context().Done():
+						s.cancelled <- struct{}{}
+						return ss.Context().Err()
+					}
+				}
+			},
+		}},
+	}
+}
+
+// newTestGateway starts the Lamp service in-process and returns a HTTP server of the gateway in front of it
+func newTestGateway(t *testing.T, opts ...grpcwot.Option) (*httptest.Server, *lampServer, *Gateway) {
+	fd, err := protofmt.ParseProtoFile("testdata/lamp.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := &lampServer{
+		fd:         fd,
+		brightness: map[string]int32{"kitchen": 40},
+		updates:    make(chan int32),
+		watchers:   make(chan struct{}, 2),
+		cancelled:  make(chan struct{}, 2),
+	}
+	server := grpc.NewServer()
+	server.RegisterService(s.serviceDesc(), s)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	td, bindings, err := grpcwot.GenerateBindings("testdata/lamp.proto", "testdata/config.json", "127.0.0.1", 50051, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(fd, td, bindings, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(g)
+	t.Cleanup(ts.Close)
+	return ts, s, g
+}
+
+func request(t *testing.T, method, url, body string) (*http.Response, map[string]interface{}) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]interface{}
+	if res.Header.Get("Content-Type") == contentTypeJSON {
+		if err := json.Unmarshal(b, &obj); err != nil {
+			t.Fatalf("Expected a JSON response, but got %s", b)
+		}
+	}
+	return res, obj
+}
+
+func TestThingDescription(t *testing.T) {
+	ts, _, _ := newTestGateway(t)
+	res, err := http.Get(ts.URL + WellKnownPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var td wot.ThingDescription
+	if err := json.NewDecoder(res.Body).Decode(&td); err != nil {
+		t.Fatal(err)
+	}
+	if td.Base != ts.URL+"/" {
+		t.Errorf("Expected the base %s/, but got %s", ts.URL, td.Base)
+	}
+	forms := td.Properties["Brightness"].Forms
+	if len(forms) != 5 || forms[0].MethodName != "GET" || forms[0].Href != "Lamp/Brightness{?room}" ||
+		forms[4].MethodName != "PUT" || forms[4].ContentType != "application/json" {
+		t.Errorf("Expected forms to read, observe and write Brightness, but got %v", forms)
+	}
+	wsObserve := "ws" + strings.TrimPrefix(ts.URL, "http") + "/Lamp/Brightness/observe{?room}"
+	if !td.Properties["Brightness"].Observable || forms[1].SubProtocol != "sse" || forms[2].Href != wsObserve ||
+		forms[3].SubProtocol != "longpoll" || forms[3].Href != "Lamp/Brightness/observe{?room}" {
+		t.Errorf("Expected forms to observe Brightness, but got %v", forms[1:4])
+	}
+	if len(td.Actions["Toggle"].Forms) != 1 {
+		t.Errorf("Expected only the forms served by the gateway, but got %v", td.Actions)
+	}
+	events := td.Events["WatchBrightness"].Forms
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/Lamp/WatchBrightness"
+	if len(events) != 2 || events[0].SubProtocol != "sse" || events[0].Href != "Lamp/WatchBrightness" ||
+		events[1].SubProtocol != "websocket" || events[1].Href != wsURL {
+		t.Errorf("Expected forms to subscribe with Server-Sent Events and WebSocket, but got %v", events)
+	}
+}
+
+func TestOperations(t *testing.T) {
+	ts, s, _ := newTestGateway(t)
+
+	res, obj := request(t, http.MethodGet, ts.URL+"/Lamp/Brightness?room=kitchen", "")
+	if res.StatusCode != http.StatusOK || obj["level"] != 40.0 || obj["room"] != "kitchen" {
+		t.Errorf("Expected the brightness of the kitchen, but got %d %v", res.StatusCode, obj)
+	}
+	if s.auth != "Bearer token" {
+		t.Errorf("Expected the Authorization header as metadata, but got %q", s.auth)
+	}
+
+	res, _ = request(t, http.MethodPut, ts.URL+"/Lamp/Brightness", `{"room": "hall", "level": 80}`)
+	if res.StatusCode != http.StatusNoContent || s.brightness["hall"] != 80 {
+		t.Errorf("Expected the brightness of the hall to be written, but got %d %v", res.StatusCode, s.brightness)
+	}
+
+	res, obj = request(t, http.MethodPost, ts.URL+"/Lamp/Toggle", `{"target": {"room": "hall"}}`)
+	if res.StatusCode != http.StatusOK || obj["on"] != true || obj["switched_at"] != 1666166400000.0 {
+		t.Errorf("Expected the lamp to be switched on, but got %d %v", res.StatusCode, obj)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	ts, s, _ := newTestGateway(t, grpcwot.WithSecurityConfig("testdata/security.json"))
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/Lamp/Brightness?room=kitchen", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", "secret")
+	req.Header.Set("X-Other", "ignored")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || s.apiKey != "secret" {
+		t.Errorf("Expected the header of the apikey scheme as metadata, but got %d %q", res.StatusCode, s.apiKey)
+	}
+	if h := credentialHeaders(map[string]wot.SecurityScheme{
+		"apikey_sc": {Scheme: "apikey", In: "header", Name: "X-API-Key"},
+		"query_sc":  {Scheme: "apikey", In: "query", Name: "key"},
+		"bearer_sc": {Scheme: "bearer", In: "header", Name: "Authorization"},
+	}); strings.Join(h, ",") != "authorization,x-api-key" {
+		t.Errorf("Expected the headers authorization and x-api-key, but got %v", h)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts, _, _ := newTestGateway(t)
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/Lamp/Brightness?room=cellar", "", http.StatusNotFound},
+		{http.MethodPost, "/Lamp/Toggle", `{"target": {"id": 1}}`, http.StatusBadRequest},
+		{http.MethodPost, "/Lamp/Toggle", `{"color": "red"}`, http.StatusBadRequest},
+		{http.MethodDelete, "/Lamp/Brightness", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/Lamp/Unknown", "", http.StatusNotFound},
+		{http.MethodPost, "/Lamp/Toggle", `{"target": {"room": "` + strings.Repeat("a", maxRequestBytes) + `"}}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, obj := request(t, tt.method, ts.URL+tt.path, tt.body)
+		if res.StatusCode != tt.status {
+			t.Errorf("%s %s: expected the status %d, but got %d %v", tt.method, tt.path, tt.status, res.StatusCode, obj)
+		}
+	}
+	_, obj := request(t, http.MethodGet, ts.URL+"/Lamp/Brightness?room=cellar", "")
+	b, _ := json.Marshal(obj["details"])
+	if expected := `[{"@type":"type.googleapis.com/google.rpc.ResourceInfo","description":"","owner":"",` +
+		`"resource_name":"cellar","resource_type":"room"}]`; string(b) != expected {
+		t.Errorf("Expected the details %s, but got %s", expected, b)
+	}
+}
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/payload"
+	"github.com/gorilla/websocket"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Properties are observed by polling their getter, the changes are pushed to the observers like event instances
+
+// ObservePath is appended to the target IRI of a property to observe it
+const ObservePath = "/observe"
+
+// subprotocolLongPoll is the subprotocol of the observeproperty form which returns the next change of the property
+const subprotocolLongPoll = "longpoll"
+
+// DefaultObserveInterval is the interval in which the getter of a property is polled,
+// if the classification config defines no ObserveInterval for it
+const DefaultObserveInterval = 5 * time.Second
+
+// longPollTimeout is the time after which a long-poll request without a change of the property is answered with
+// 204 No Content, so the consumer repeats the request
+const longPollTimeout = 30 * time.Second
+
+// observeHref returns the target IRI to observe a property from the target IRI to read it, e.g.
+// Lamp/Brightness{?room} results in Lamp/Brightness/observe{?room}
+func observeHref(href string) string {
+	parts := strings.SplitN(href, "{", 2)
+	if len(parts) == 1 {
+		return href + ObservePath
+	}
+	return parts[0] + ObservePath + "{" + parts[1]
+}
+
+// poll returns a function which polls the getter in the interval until the value of the property differs from the
+// last value. The first value read is not reported, as it is no change
+func (g *Gateway) poll(ctx context.Context, rt route, req *dynamic.Message) func() ([]byte, error) {
+	interval := rt.binding.ObserveInterval
+	if interval == 0 {
+		interval = DefaultObserveInterval
+	}
+	var last []byte
+	read := func() ([]byte, error) {
+		res, err := g.stub.InvokeRpc(ctx, rt.method, req)
+		if err != nil {
+			return nil, err
+		}
+		obj, err := payload.MessageToJSON(res)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(obj)
+	}
+	return func() ([]byte, error) {
+		if last == nil {
+			var err error
+			if last, err = read(); err != nil {
+				return nil, err
+			}
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-ticker.C:
+			}
+			value, err := read()
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(value, last) {
+				last = value
+				return value, nil
+			}
+		}
+	}
+}
+
+// serveObservation pushes the changes of a property over a WebSocket, as Server-Sent Events or answers a long-poll
+// request with the next change, depending on the request
+func (g *Gateway) serveObservation(w http.ResponseWriter, r *http.Request, rt route) {
+	req := dynamic.NewMessage(rt.method.GetInputType())
+	if err := requestMessage(r, rt, req); err != nil {
+		g.writeStatus(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+	credentials := g.credentials(r)
+	key := strings.Join(append([]string{rt.binding.Href + ObservePath, r.URL.RawQuery}, credentials...), "\x00")
+	sub, err := g.subscribe(key, func(ctx context.Context) (func() ([]byte, error), error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, credentials...)
+		return g.poll(ctx, rt, req), nil
+	})
+	if err != nil {
+		g.writeStatus(w, statusFromError(err))
+		return
+	}
+	defer g.unsubscribe(key, sub)
+	switch {
+	case websocket.IsWebSocketUpgrade(r):
+		serveWebSocket(w, r, sub)
+	case strings.Contains(r.Header.Get("Accept"), "text/event-stream"):
+		g.serveSSE(w, r, sub)
+	default:
+		g.serveLongPoll(w, r, sub)
+	}
+}
+
+// serveLongPoll answers the request with the next change of the property
+func (g *Gateway) serveLongPoll(w http.ResponseWriter, r *http.Request, sub *subscriber) {
+	timeout := time.NewTimer(longPollTimeout)
+	defer timeout.Stop()
+	select {
+	case value, ok := <-sub.events:
+		if !ok {
+			g.writeStatus(w, sub.err)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		_, _ = w.Write(value)
+	case <-timeout.C:
+		w.WriteHeader(http.StatusNoContent)
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// awaitObservers waits until the running streams have n subscribers
+func awaitObservers(t *testing.T, g *Gateway, n int) {
+	for deadline := time.Now().Add(time.Second); ; {
+		g.streams.mu.Lock()
+		count := 0
+		for _, v := range g.streams.running {
+			count += len(v.subscribers)
+		}
+		g.streams.mu.Unlock()
+		if count == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d observers, but got %d", n, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestObserveHref(t *testing.T) {
+	for href, expected := range map[string]string{
+		"Lamp/Brightness":        "Lamp/Brightness/observe",
+		"Lamp/Brightness{?room}": "Lamp/Brightness/observe{?room}",
+	} {
+		if h := observeHref(href); h != expected {
+			t.Errorf("Expected %s, but got %s", expected, h)
+		}
+	}
+}
+
+func TestObserveProperty(t *testing.T) {
+	ts, _, g := newTestGateway(t)
+
+	longPoll := make(chan string)
+	go func() {
+		res, err := http.Get(ts.URL + "/Lamp/Brightness/observe?room=kitchen")
+		if err != nil {
+			longPoll <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		b, _ := ioutil.ReadAll(res.Body)
+		longPoll <- res.Status + " " + strings.TrimSpace(string(b))
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/Lamp/Brightness/observe?room=kitchen", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	awaitObservers(t, g, 2)
+	// let the poller read the initial value before it is changed
+	time.Sleep(50 * time.Millisecond)
+
+	if res, _ := request(t, http.MethodPut, ts.URL+"/Lamp/Brightness", `{"room": "kitchen", "level": 90}`); res.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected the brightness to be written, but got %d", res.StatusCode)
+	}
+	select {
+	case v := <-longPoll:
+		if v != "200 OK {\"level\":90,\"room\":\"kitchen\"}" {
+			t.Errorf("Expected the long-poll request to return the change, but got %s", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the long-poll request to return the change")
+	}
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	if err != nil || line != "data: {\"level\":90,\"room\":\"kitchen\"}\n" {
+		t.Errorf("Expected the change as Server-Sent Event, but got %q %v", line, err)
+	}
+
+	cancel()
+	awaitObservers(t, g, 0)
+}
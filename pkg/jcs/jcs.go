@@ -0,0 +1,191 @@
+// Package jcs serializes JSON documents with the JSON Canonicalization Scheme (RFC 8785), so that equal documents
+// result in equal bytes regardless of the order of their members, their whitespace and the notation of their numbers
+package jcs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Transform canonicalizes the JSON document
+func Transform(data []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: data after the top-level value")
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Marshal returns the canonical JSON encoding of v
+func Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Transform(data)
+}
+
+// Hash returns the hex encoded SHA-256 hash of the canonical form of the JSON document
+func Hash(data []byte) (string, error) {
+	canonical, err := Transform(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// write serializes a decoded JSON value
+func write(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		s, err := formatNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case string:
+		writeString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for k, e := range v {
+			if k > 0 {
+				buf.WriteByte(',')
+			}
+			if err := write(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		// the members are sorted by the UTF-16 code units of their names
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		buf.WriteByte('{')
+		for k, key := range keys {
+			if k > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, key)
+			buf.WriteByte(':')
+			if err := write(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported JSON value %v", v)
+	}
+	return nil
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for k := 0; k < len(ua) && k < len(ub); k++ {
+		if ua[k] != ub[k] {
+			return ua[k] < ub[k]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// writeString writes a JSON string, only the quotation mark, the reverse solidus and control characters are escaped
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatNumber serializes a number like ECMAScript's Number.prototype.toString as the IEEE 754 double closest to it
+func formatNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("the number %s can not be represented as IEEE 754 double", n)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+	// the shortest digits which identify f, f = 0.digits * 10^exp
+	mantissa, e := splitExponent(strconv.FormatFloat(f, 'e', -1, 64))
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp := e + 1
+	k := len(digits)
+	switch {
+	case k <= exp && exp <= 21:
+		return sign + digits + strings.Repeat("0", exp-k), nil
+	case 0 < exp && exp <= 21:
+		return sign + digits[:exp] + "." + digits[exp:], nil
+	case -6 < exp && exp <= 0:
+		return sign + "0." + strings.Repeat("0", -exp) + digits, nil
+	}
+	s := sign + digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	if exp-1 >= 0 {
+		return s + "e+" + strconv.Itoa(exp-1), nil
+	}
+	return s + "e" + strconv.Itoa(exp-1), nil
+}
+
+// splitExponent splits a number in the 'e' format of strconv into its mantissa and exponent
+func splitExponent(s string) (string, int) {
+	k := strings.IndexByte(s, 'e')
+	e, _ := strconv.Atoi(s[k+1:])
+	return s[:k], e
+}
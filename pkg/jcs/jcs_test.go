@@ -0,0 +1,136 @@
+package jcs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"primitives (RFC 8785 section 3.2.2)",
+			`{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+				`"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			"sorting (RFC 8785 section 3.2.3)",
+			`{"\u20ac": "Euro Sign", "\r": "Carriage Return", "\ufb33": "Hebrew Letter Dalet With Dagesh", "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face", "\u0080": "Control", "\u00f6": "Latin Small Letter O With Diaeresis"}`,
+			`{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","` + "\u00f6" +
+				`":"Latin Small Letter O With Diaeresis","` + "\u20ac" + `":"Euro Sign","` + "\U0001f600" +
+				`":"Emoji: Grinning Face","` + "\ufb33" + `":"Hebrew Letter Dalet With Dagesh"}`,
+		},
+		{
+			"nested",
+			`[{"b": {"y": [], "x": {}}, "a": "<&>"}, -0, 1.0]`,
+			`[{"a":"<&>","b":{"x":{},"y":[]}},0,1]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Transform([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Transform() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformInvalid(t *testing.T) {
+	for _, in := range []string{`{"a": 1`, `{} []`, `1e400`} {
+		if _, err := Transform([]byte(in)); err == nil {
+			t.Errorf("Transform(%s) expected an error", in)
+		}
+	}
+}
+
+// cf. RFC 8785 appendix B
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		f := math.Float64frombits(tt.bits)
+		got, err := formatNumber(json.Number(strconv.FormatFloat(f, 'g', -1, 64)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("formatNumber(%016x) = %s, want %s", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	a, err := Hash([]byte(`{"b": 1.0, "a": [true]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Hash([]byte(`{"a":[true],"b":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("Hash() of equal documents differs: %s != %s", a, b)
+	}
+	sum := sha256.Sum256([]byte(`{"a":[true],"b":1}`))
+	if want := hex.EncodeToString(sum[:]); a != want {
+		t.Errorf("Hash() = %s, want %s", a, want)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	got, err := Marshal(struct {
+		Title string            `json:"title"`
+		Links map[string]string `json:"links"`
+		Count float64           `json:"count"`
+	}{"<Lamp>", map[string]string{"z": "1", "a": "2"}, 1e21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"count":1e+21,"links":{"a":"2","z":"1"},"title":"<Lamp>"}`; string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
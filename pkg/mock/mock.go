@@ -0,0 +1,312 @@
+// Package mock serves the gRPC service of a proto file without an implementation, so consumers of its Thing
+// Description can be developed and tested without the device. The responses are driven by the data schemas of the TD
+package mock
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/payload"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DefaultInterval is the interval in which the events are emitted if no other interval is given
+const DefaultInterval = time.Second
+
+// Server implements every RPC of a proto file with dynamic messages. Properties are backed by an in-memory store,
+// so the getter returns what was last set, actions return samples of their output and events emit samples of their
+// data in a fixed interval. RPCs which are not bound to an operation of the TD return empty messages
+type Server struct {
+	fd       *desc.FileDescriptor
+	td       wot.ThingDescription
+	interval time.Duration
+	bindings map[string]grpcwot.Binding // full method -> binding
+
+	mu          sync.Mutex
+	properties  map[string]map[string]interface{}
+	invocations int
+}
+
+// Option configures the mock server
+type Option func(*Server)
+
+// WithInterval sets the interval in which the events are emitted
+func WithInterval(d time.Duration) Option {
+	return func(s *Server) {
+		s.interval = d
+	}
+}
+
+// New creates a mock server for the services of the proto file, which serves the operations of the bindings
+func New(fd *desc.FileDescriptor, td wot.ThingDescription, bindings []grpcwot.Binding, opts ...Option) (*Server, error) {
+	s := &Server{
+		fd:         fd,
+		td:         td,
+		interval:   DefaultInterval,
+		bindings:   map[string]grpcwot.Binding{},
+		properties: map[string]map[string]interface{}{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.interval <= 0 {
+		return nil, fmt.Errorf("the interval of the events must be positive, but is %v", s.interval)
+	}
+	for _, b := range bindings {
+		if fd.FindService(b.Service) == nil {
+			return nil, fmt.Errorf("service %s not found in %s", b.Service, fd.GetName())
+		}
+		s.bindings[b.FullMethod()] = b
+	}
+	return s, nil
+}
+
+// Register registers all services of the proto file at the gRPC server
+func (s *Server) Register(gs *grpc.Server) {
+	for _, sd := range s.fd.GetServices() {
+		gs.RegisterService(s.serviceDesc(sd), s)
+	}
+}
+
+// serviceDesc describes the service with handlers for all of its RPCs
+func (s *Server) serviceDesc(sd *desc.ServiceDescriptor) *grpc.ServiceDesc {
+	d := &grpc.ServiceDesc{
+		ServiceName: sd.GetFullyQualifiedName(),
+		HandlerType: (*interface{})(nil),
+		Metadata:    s.fd.GetName(),
+	}
+	for _, md := range sd.GetMethods() {
+		md := md
+		if !md.IsClientStreaming() && !md.IsServerStreaming() {
+			d.Methods = append(d.Methods, grpc.MethodDesc{
+				MethodName: md.GetName(),
+				Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+					req := dynamic.NewMessage(md.GetInputType())
+					if err := dec(req); err != nil {
+						return nil, err
+					}
+					if interceptor == nil {
+						return s.unary(md, req)
+					}
+					info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(md)}
+					return interceptor(ctx, req, info, func(_ context.Context, req interface{}) (interface{}, error) {
+						return s.unary(md, req.(*dynamic.Message))
+					})
+				},
+			})
+			continue
+		}
+		d.Streams = append(d.Streams, grpc.StreamDesc{
+			StreamName: md.GetName(),
+			Handler: func(_ interface{}, stream grpc.ServerStream) error {
+				return s.stream(md, stream)
+			},
+			ServerStreams: md.IsServerStreaming(),
+			ClientStreams: md.IsClientStreaming(),
+		})
+	}
+	return d
+}
+
+// binding returns the binding of the RPC and the name of the affordance it serves
+func (s *Server) binding(md *desc.MethodDescriptor) (grpcwot.Binding, string) {
+	b := s.bindings[fullMethod(md)]
+	return b, strings.TrimPrefix(b.Href, s.td.Title+"/")
+}
+
+// fullMethod returns the full method name of the RPC, e.g. /acme.lamp.v1.Lamp/GetBrightness
+func fullMethod(md *desc.MethodDescriptor) string {
+	return "/" + md.GetService().GetFullyQualifiedName() + "/" + md.GetName()
+}
+
+// unary serves a unary RPC according to the operation it is bound to
+func (s *Server) unary(md *desc.MethodDescriptor, req *dynamic.Message) (interface{}, error) {
+	b, name := s.binding(md)
+	switch b.Op {
+	case "readproperty":
+		return response(md, s.property(name))
+	case "writeproperty":
+		value, err := payload.MessageToJSON(req)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return response(md, s.writeProperty(name, value))
+	case "invokeaction":
+		s.mu.Lock()
+		n := s.invocations
+		s.invocations++
+		s.mu.Unlock()
+		if a, ok := s.td.Actions[name]; ok && a.Output != nil {
+			return response(md, Sample(*a.Output, n))
+		}
+	}
+	return response(md, nil)
+}
+
+// stream serves a streaming RPC, events emit samples of their data until the client cancels the call
+func (s *Server) stream(md *desc.MethodDescriptor, stream grpc.ServerStream) error {
+	if md.IsClientStreaming() {
+		// the requests are drained, client streams are not bound to operations
+		for {
+			err := stream.RecvMsg(dynamic.NewMessage(md.GetInputType()))
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	} else if err := stream.RecvMsg(dynamic.NewMessage(md.GetInputType())); err != nil {
+		return err
+	}
+	b, name := s.binding(md)
+	e, ok := s.td.Events[name]
+	if b.Op != "subscribeevent" || !ok || e.Data == nil || !md.IsServerStreaming() {
+		res, err := response(md, nil)
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(res)
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for n := 0; ; n++ {
+		res, err := response(md, Sample(*e.Data, n))
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(res); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// property returns the value of the property, which is initialized with a sample of its data schema
+func (s *Server) property(name string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.properties[name]
+	if !ok {
+		value = map[string]interface{}{}
+		if p, found := s.td.Properties[name]; found {
+			if obj, isObj := Sample(p.DataSchema, 0).(map[string]interface{}); isObj {
+				value = obj
+			}
+		}
+		s.properties[name] = value
+	}
+	return value
+}
+
+// writeProperty merges the fields of the value into the stored value of the property
+func (s *Server) writeProperty(name string, value map[string]interface{}) map[string]interface{} {
+	stored := s.property(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	merged := map[string]interface{}{}
+	for k, v := range stored {
+		merged[k] = v
+	}
+	for k, v := range value {
+		merged[k] = v
+	}
+	s.properties[name] = merged
+	return merged
+}
+
+// response creates the response message of the RPC from a JSON value, fields which are not part of the response
+// message are ignored
+func response(md *desc.MethodDescriptor, value interface{}) (*dynamic.Message, error) {
+	m := dynamic.NewMessage(md.GetOutputType())
+	obj, _ := value.(map[string]interface{})
+	if err := payload.JSONToMessage(m, conform(md.GetOutputType(), obj)); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return m, nil
+}
+
+// conform restricts the JSON object to the fields of the message. Strings of bytes fields are encoded in base64,
+// as the data schemas describe bytes as plain strings
+func conform(md *desc.MessageDescriptor, obj map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{}
+	for k, v := range obj {
+		if fd := md.FindFieldByName(k); fd != nil {
+			c[k] = conformField(fd, v)
+			continue
+		}
+		alternative, ok := v.(map[string]interface{})
+		if oneof := findOneOf(md, k); oneof == nil || !ok {
+			continue
+		}
+		for n, value := range alternative {
+			if fd := md.FindFieldByName(n); fd != nil && fd.GetOneOf() != nil {
+				c[k] = map[string]interface{}{n: conformField(fd, value)}
+				break
+			}
+		}
+	}
+	return c
+}
+
+// conformField conforms the JSON value of a field to its type
+func conformField(fd *desc.FieldDescriptor, v interface{}) interface{} {
+	if arr, ok := v.([]interface{}); ok && fd.IsRepeated() && !fd.IsMap() {
+		c := make([]interface{}, len(arr))
+		for k, e := range arr {
+			c[k] = conformValue(fd, e)
+		}
+		return c
+	}
+	if obj, ok := v.(map[string]interface{}); ok && fd.IsMap() {
+		c := map[string]interface{}{}
+		for k, e := range obj {
+			c[k] = conformValue(fd.GetMapValueType(), e)
+		}
+		return c
+	}
+	return conformValue(fd, v)
+}
+
+// conformValue conforms a single JSON value to the type of the field
+func conformValue(fd *desc.FieldDescriptor, v interface{}) interface{} {
+	switch fd.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		if s, ok := v.(string); ok {
+			if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+				return base64.StdEncoding.EncodeToString([]byte(s))
+			}
+		}
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		if obj, ok := v.(map[string]interface{}); ok {
+			return conform(fd.GetMessageType(), obj)
+		}
+	}
+	return v
+}
+
+// findOneOf returns the (non-synthetic) oneof of the message with the given name
+func findOneOf(md *desc.MessageDescriptor, name string) *desc.OneOfDescriptor {
+	for _, o := range md.GetOneOfs() {
+		if o.GetName() == name && !o.IsSynthetic() {
+			return o
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mock
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Interactions-HSG/grpcwot"
+	"github.com/Interactions-HSG/grpcwot/pkg/consumer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestThing serves the mock of testdata/sensor.proto in-process and consumes it with its TD
+func newTestThing(t *testing.T, opts ...Option) *consumer.ConsumedThing {
+	td, bindings, err := grpcwot.GenerateBindings("testdata/sensor.proto", "", "127.0.0.1", 50051)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := consumer.LoadProtoFile("testdata/sensor.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(files[0], td, bindings, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	m.Register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	c, err := consumer.New(td, files, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestProperty(t *testing.T) {
+	c := newTestThing(t)
+	ctx := context.Background()
+
+	b, err := c.ReadPropertyJSON(ctx, "Threshold", nil)
+	if err != nil || string(b) != `{"celsius":0,"unit":"sample0"}` {
+		t.Errorf("Expected the initial value to be a sample of the property, but got %s %v", b, err)
+	}
+	if err := c.WritePropertyJSON(ctx, "Threshold", []byte(`{"celsius": 42.5, "unit": "C"}`)); err != nil {
+		t.Fatal(err)
+	}
+	b, err = c.ReadPropertyJSON(ctx, "Threshold", nil)
+	if err != nil || string(b) != `{"celsius":42.5,"unit":"C"}` {
+		t.Errorf("Expected the value which was last written, but got %s %v", b, err)
+	}
+}
+
+func TestAction(t *testing.T) {
+	c := newTestThing(t)
+	output, err := c.InvokeAction(context.Background(), "Calibrate", map[string]interface{}{"samples": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output["ok"] != true || output["report"] == "" || len(output["offsets"].([]interface{})) != 1 {
+		t.Errorf("Expected a sample of the output, but got %v", output)
+	}
+	if result, ok := output["result"].(map[string]interface{}); !ok || result["message"] != "sample0" {
+		t.Errorf("Expected the first alternative of the oneof, but got %v", output["result"])
+	}
+	output, err = c.InvokeAction(context.Background(), "Calibrate", nil)
+	if err != nil || output["ok"] != false {
+		t.Errorf("Expected the samples of consecutive invocations to differ, but got %v %v", output, err)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	c := newTestThing(t, WithInterval(10*time.Millisecond))
+	data := make(chan map[string]interface{}, 16)
+	sub, err := c.SubscribeEvent(context.Background(), "WatchMeasurements", nil,
+		func(d map[string]interface{}) { data <- d })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Stop()
+	for n, expected := range []string{"sample0", "sample1", "sample2"} {
+		select {
+		case d := <-data:
+			if d["sensor"] != expected {
+				t.Errorf("Expected the sample %d of the event data, but got %v", n, d)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the event to be emitted in the interval")
+		}
+	}
+}
+
+func TestInvalidInterval(t *testing.T) {
+	td, bindings, err := grpcwot.GenerateBindings("testdata/sensor.proto", "", "127.0.0.1", 50051)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := consumer.LoadProtoFile("testdata/sensor.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(files[0], td, bindings, WithInterval(0)); err == nil {
+		t.Errorf("Expected an error for an interval which is not positive")
+	}
+}
@@ -0,0 +1,162 @@
+package mock
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// sampleTime is the time of the first sample of date and time formats
+var sampleTime = time.Date(2022, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// Sample generates the n-th sample value conforming to the DataSchema. Samples with different n differ where the
+// schema allows it, so a stream of samples shows changing values. Patterns of strings are not considered
+func Sample(ds wot.DataSchema, n int) interface{} {
+	switch {
+	case ds.Const != nil:
+		return ds.Const
+	case len(ds.Enum) != 0:
+		return ds.Enum[n%len(ds.Enum)]
+	case ds.Default != nil && n == 0:
+		return ds.Default
+	case len(ds.OneOf) != 0:
+		return Sample(ds.OneOf[0], n)
+	}
+	switch ds.DataType {
+	case "boolean":
+		return n%2 == 0
+	case "integer":
+		return int64(sampleNumber(ds, n, 1))
+	case "number":
+		return sampleNumber(ds, n, 0.5)
+	case "string":
+		return sampleString(ds, n)
+	case "array":
+		return sampleArray(ds, n)
+	case "object":
+		return sampleObject(ds, n)
+	case "null":
+		return nil
+	}
+	return nil
+}
+
+// sampleNumber returns a number within the bounds of the schema, consecutive samples differ by step
+func sampleNumber(ds wot.DataSchema, n int, step float64) float64 {
+	v := float64(n) * step
+	if ds.NumberSchema == nil {
+		return v
+	}
+	if ds.MultipleOf != nil && *ds.MultipleOf > 0 {
+		step = *ds.MultipleOf
+		v = float64(n) * step
+	}
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if ds.Minimum != nil {
+		lo = *ds.Minimum
+	}
+	if ds.ExclusiveMinimum != nil {
+		lo = math.Max(lo, *ds.ExclusiveMinimum+step)
+	}
+	if ds.Maximum != nil {
+		hi = *ds.Maximum
+	}
+	if ds.ExclusiveMaximum != nil {
+		hi = math.Min(hi, *ds.ExclusiveMaximum-step)
+	}
+	if !math.IsInf(lo, 0) {
+		// start at the smallest valid multiple of the step
+		lo = math.Ceil(lo/step) * step
+		v += lo
+	}
+	if !math.IsInf(hi, 0) && v > hi {
+		if math.IsInf(lo, 0) {
+			lo = math.Min(0, hi)
+		}
+		span := math.Floor((hi-lo)/step) + 1
+		if span < 1 {
+			return lo
+		}
+		v = lo + math.Mod(float64(n), span)*step
+	}
+	return v
+}
+
+// sampleString returns a string of the format of the schema within its length limits
+func sampleString(ds wot.DataSchema, n int) string {
+	var s string
+	switch ds.Format {
+	case "date-time":
+		s = sampleTime.Add(time.Duration(n) * time.Second).Format(time.RFC3339)
+	case "date":
+		s = sampleTime.AddDate(0, 0, n).Format("2006-01-02")
+	case "time":
+		s = sampleTime.Add(time.Duration(n) * time.Second).Format("15:04:05")
+	case "email":
+		s = fmt.Sprintf("device%d@example.com", n)
+	case "hostname":
+		s = fmt.Sprintf("device%d.example.com", n)
+	case "ipv4":
+		s = fmt.Sprintf("192.0.2.%d", n%256)
+	case "ipv6":
+		s = fmt.Sprintf("2001:db8::%x", n)
+	case "uri":
+		s = fmt.Sprintf("https://example.com/devices/%d", n)
+	case "uri-reference":
+		s = fmt.Sprintf("devices/%d", n)
+	case "uuid":
+		s = fmt.Sprintf("00000000-0000-4000-8000-%012d", n)
+	default:
+		s = fmt.Sprintf("sample%d", n)
+	}
+	if ds.StringSchema == nil {
+		return s
+	}
+	if ds.MinLength != nil && len(s) < *ds.MinLength {
+		s += strings.Repeat("x", *ds.MinLength-len(s))
+	}
+	if ds.MaxLength != nil && len(s) > *ds.MaxLength {
+		s = s[len(s)-*ds.MaxLength:]
+	}
+	return s
+}
+
+// sampleArray returns an array with the minimum number of items, but at least one if allowed
+func sampleArray(ds wot.DataSchema, n int) []interface{} {
+	items := []interface{}{}
+	if ds.ArraySchema == nil || ds.Items == nil {
+		return items
+	}
+	count := 1
+	if ds.MinItems != nil && *ds.MinItems > count {
+		count = *ds.MinItems
+	}
+	if ds.MaxItems != nil && *ds.MaxItems < count {
+		count = *ds.MaxItems
+	}
+	for k := 0; k < count; k++ {
+		items = append(items, Sample(*ds.Items, n+k))
+	}
+	return items
+}
+
+// sampleObject returns an object with samples of all properties
+func sampleObject(ds wot.DataSchema, n int) map[string]interface{} {
+	obj := map[string]interface{}{}
+	if ds.ObjectSchema == nil {
+		return obj
+	}
+	names := make([]string, 0, len(ds.Properties))
+	for k := range ds.Properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		obj[k] = Sample(ds.Properties[k], n)
+	}
+	return obj
+}
@@ -0,0 +1,67 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+func float(f float64) *float64 { return &f }
+
+func length(l int) *int { return &l }
+
+func TestSampleNumber(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ds       wot.DataSchema
+		n        int
+		expected interface{}
+	}{
+		{"unbounded", wot.DataSchema{DataType: "integer"}, 3, int64(3)},
+		{"minimum", wot.DataSchema{DataType: "integer", NumberSchema: &wot.NumberSchema{Minimum: float(10)}}, 2, int64(12)},
+		{"maximum", wot.DataSchema{DataType: "integer", NumberSchema: &wot.NumberSchema{Minimum: float(1), Maximum: float(3)}}, 4, int64(2)},
+		{"exclusive", wot.DataSchema{DataType: "integer", NumberSchema: &wot.NumberSchema{ExclusiveMinimum: float(0), ExclusiveMaximum: float(2)}}, 5, int64(1)},
+		{"multipleOf", wot.DataSchema{DataType: "number", NumberSchema: &wot.NumberSchema{Minimum: float(1), MultipleOf: float(0.25)}}, 1, 1.25},
+		{"negative", wot.DataSchema{DataType: "number", NumberSchema: &wot.NumberSchema{Maximum: float(-10)}}, 3, -10.0},
+		{"const", wot.DataSchema{DataType: "integer", Const: 7}, 1, 7},
+		{"enum", wot.DataSchema{DataType: "string", Enum: []interface{}{"on", "off"}}, 3, "off"},
+	} {
+		if v := Sample(tc.ds, tc.n); v != tc.expected {
+			t.Errorf("%s: expected %v (%T), but got %v (%T)", tc.name, tc.expected, tc.expected, v, v)
+		}
+	}
+}
+
+func TestSampleString(t *testing.T) {
+	for _, tc := range []struct {
+		ds       wot.DataSchema
+		expected string
+	}{
+		{wot.DataSchema{DataType: "string", Format: "date-time"}, "2022-01-01T08:00:01Z"},
+		{wot.DataSchema{DataType: "string", Format: "uuid"}, "00000000-0000-4000-8000-000000000001"},
+		{wot.DataSchema{DataType: "string", StringSchema: &wot.StringSchema{MinLength: length(10)}}, "sample1xxx"},
+		{wot.DataSchema{DataType: "string", StringSchema: &wot.StringSchema{MaxLength: length(3)}}, "le1"},
+	} {
+		if v := Sample(tc.ds, 1); v != tc.expected {
+			t.Errorf("Expected %s for the format %q, but got %v", tc.expected, tc.ds.Format, v)
+		}
+	}
+}
+
+func TestSampleObject(t *testing.T) {
+	ds := wot.DataSchema{
+		DataType: "object",
+		ObjectSchema: &wot.ObjectSchema{Properties: map[string]wot.DataSchema{
+			"tags": {DataType: "array", ArraySchema: &wot.ArraySchema{Items: &wot.DataSchema{DataType: "string"}, MinItems: length(2)}},
+			"on":   {DataType: "boolean"},
+			"room": {OneOf: []wot.DataSchema{{DataType: "string"}, {DataType: "null"}}},
+		}},
+	}
+	obj := Sample(ds, 0).(map[string]interface{})
+	if tags := obj["tags"].([]interface{}); len(tags) != 2 || tags[1] != "sample1" {
+		t.Errorf("Expected the minimum number of items, but got %v", obj["tags"])
+	}
+	if obj["on"] != true || obj["room"] != "sample0" {
+		t.Errorf("Expected samples of all properties, but got %v", obj)
+	}
+}
@@ -0,0 +1,282 @@
+// Package payload converts between dynamic protobuf messages and the JSON payloads described by the data schemas
+// of the generated Thing Descriptions
+package payload
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The payloads follow the data schemas of the TD rather than the canonical JSON mapping of Protocol Buffers:
+// fields are named as in the proto file, 64-bit integers are numbers and the alternatives of a oneof are nested in an
+// object named by the oneof, e.g. {"target": {"name": "lamp"}}
+
+// MessageToJSON converts a message into the JSON value described by its data schema
+func MessageToJSON(pm proto.Message) (map[string]interface{}, error) {
+	m, err := dynamic.AsDynamicMessage(pm)
+	if err != nil {
+		return nil, err
+	}
+	obj := map[string]interface{}{}
+	for _, fd := range m.GetMessageDescriptor().GetFields() {
+		oneof := fd.GetOneOf()
+		explicit := oneof != nil || fd.GetMessageType() != nil && !fd.IsRepeated() && !fd.IsMap()
+		if explicit && !m.HasField(fd) {
+			continue
+		}
+		v, err := fieldToJSON(fd, m.GetField(fd))
+		if err != nil {
+			return nil, err
+		}
+		if oneof != nil && !oneof.IsSynthetic() {
+			obj[oneof.GetName()] = map[string]interface{}{fd.GetName(): v}
+			continue
+		}
+		obj[fd.GetName()] = v
+	}
+	return obj, nil
+}
+
+// fieldToJSON converts the value of a field, repeated fields are arrays and maps are objects
+func fieldToJSON(fd *desc.FieldDescriptor, v interface{}) (interface{}, error) {
+	switch {
+	case fd.IsMap():
+		obj := map[string]interface{}{}
+		for k, e := range v.(map[interface{}]interface{}) {
+			value, err := valueToJSON(fd.GetMapValueType(), e)
+			if err != nil {
+				return nil, err
+			}
+			obj[fmt.Sprint(k)] = value
+		}
+		return obj, nil
+	case fd.IsRepeated():
+		values := v.([]interface{})
+		arr := make([]interface{}, len(values))
+		for k, e := range values {
+			value, err := valueToJSON(fd, e)
+			if err != nil {
+				return nil, err
+			}
+			arr[k] = value
+		}
+		return arr, nil
+	default:
+		return valueToJSON(fd, v)
+	}
+}
+
+// valueToJSON converts a single value of a field
+func valueToJSON(fd *desc.FieldDescriptor, v interface{}) (interface{}, error) {
+	switch fd.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return MessageToJSON(v.(proto.Message))
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		if e := fd.GetEnumType().FindValueByNumber(v.(int32)); e != nil {
+			return e.GetName(), nil
+		}
+		return v, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return base64.StdEncoding.EncodeToString(v.([]byte)), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		if f := float64(v.(float32)); math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("the value of the field %s is not a finite number", fd.GetName())
+		}
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		if f := v.(float64); math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("the value of the field %s is not a finite number", fd.GetName())
+		}
+	}
+	return v, nil
+}
+
+// JSONToMessage sets the fields of the message from the JSON object
+func JSONToMessage(m *dynamic.Message, obj map[string]interface{}) error {
+	md := m.GetMessageDescriptor()
+	for k, v := range obj {
+		if fd := md.FindFieldByName(k); fd != nil {
+			if err := SetField(m, fd, v); err != nil {
+				return err
+			}
+			continue
+		}
+		oneof := findOneOf(md, k)
+		if oneof == nil {
+			return fmt.Errorf("unknown field %s of %s", k, md.GetName())
+		}
+		alternative, ok := v.(map[string]interface{})
+		if !ok || len(alternative) != 1 {
+			return fmt.Errorf("the oneof %s must hold exactly one of its fields", k)
+		}
+		for n, value := range alternative {
+			fd := md.FindFieldByName(n)
+			if fd == nil || fd.GetOneOf() != oneof {
+				return fmt.Errorf("%s is not a field of the oneof %s", n, k)
+			}
+			if err := SetField(m, fd, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findOneOf returns the (non-synthetic) oneof of the message with the given name
+func findOneOf(md *desc.MessageDescriptor, name string) *desc.OneOfDescriptor {
+	for _, o := range md.GetOneOfs() {
+		if o.GetName() == name && !o.IsSynthetic() {
+			return o
+		}
+	}
+	return nil
+}
+
+// SetField sets a field of the message from a JSON value, null leaves the field unset
+func SetField(m *dynamic.Message, fd *desc.FieldDescriptor, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	switch {
+	case fd.IsMap():
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("the field %s must be an object", fd.GetName())
+		}
+		for k, e := range obj {
+			key, err := jsonToValue(fd.GetMapKeyType(), k)
+			if err != nil {
+				return err
+			}
+			value, err := jsonToValue(fd.GetMapValueType(), e)
+			if err != nil {
+				return err
+			}
+			if err := m.TryPutMapField(fd, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case fd.IsRepeated():
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("the field %s must be an array", fd.GetName())
+		}
+		for _, e := range arr {
+			value, err := jsonToValue(fd, e)
+			if err != nil {
+				return err
+			}
+			if err := m.TryAddRepeatedField(fd, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		value, err := jsonToValue(fd, v)
+		if err != nil {
+			return err
+		}
+		return m.TrySetField(fd, value)
+	}
+}
+
+// jsonToValue converts a single JSON value into the value of the field. Numbers and booleans may be given as strings,
+// as URI variables are always strings
+func jsonToValue(fd *desc.FieldDescriptor, v interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("invalid value %v for the field %s", v, fd.GetName())
+	s := fmt.Sprint(v)
+	switch fd.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, invalid
+		}
+		nested := dynamic.NewMessage(fd.GetMessageType())
+		return nested, JSONToMessage(nested, obj)
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		if e := fd.GetEnumType().FindValueByName(s); e != nil {
+			return e.GetNumber(), nil
+		}
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, invalid
+		}
+		return int32(i), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		if _, ok := v.(string); !ok {
+			return nil, invalid
+		}
+		return s, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, invalid
+		}
+		return b, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, invalid
+		}
+		return b, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, invalid
+		}
+		return float32(f), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return f, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, invalid
+		}
+		return int32(i), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return i, nil
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
+		i, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, invalid
+		}
+		return uint32(i), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
+		i, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return i, nil
+	}
+	return nil, invalid
+}
+
+// DecodeJSON decodes a JSON object, numbers are kept as json.Number to preserve 64-bit integers
+func DecodeJSON(data []byte) (map[string]interface{}, error) {
+	var obj map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
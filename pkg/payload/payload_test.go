@@ -0,0 +1,90 @@
+package payload
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+func parseMessage(t *testing.T, src, name string) *desc.MessageDescriptor {
+	p := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"test.proto": src})}
+	fds, err := p.ParseFiles("test.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fds[0].FindMessage(name)
+}
+
+func TestRoundTrip(t *testing.T) {
+	md := parseMessage(t, `syntax = "proto3";
+package test;
+enum Mode { MODE_UNSPECIFIED = 0; MODE_ECO = 1; }
+message Reading {
+  int64 timestamp = 1;
+  repeated double values = 2;
+  Mode mode = 3;
+  bytes raw = 4;
+  optional string note = 5;
+  oneof source {
+    string sensor = 6;
+    int32 channel = 7;
+  }
+  map<string, int32> counts = 8;
+  Reading previous = 9;
+}`, "test.Reading")
+	in := `{"timestamp": 9007199254740993, "values": [1.5, 2], "mode": "MODE_ECO", "raw": "AQI=",
+		"source": {"channel": 3}, "counts": {"a": 1}, "previous": {"timestamp": 1}}`
+	obj, err := DecodeJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := dynamic.NewMessage(md)
+	if err := JSONToMessage(m, obj); err != nil {
+		t.Fatal(err)
+	}
+	if m.GetFieldByName("timestamp") != int64(9007199254740993) || m.GetFieldByName("channel") != int32(3) {
+		t.Errorf("Expected the timestamp and the channel to be set, but got %v", m)
+	}
+	out, err := MessageToJSON(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"counts":{"a":1},"mode":"MODE_ECO","previous":{"counts":{},"mode":"MODE_UNSPECIFIED","raw":"","timestamp":1,"values":[]},` +
+		`"raw":"AQI=","source":{"channel":3},"timestamp":9007199254740993,"values":[1.5,2]}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, but got %s", expected, b)
+	}
+}
+
+func TestInvalidPayloads(t *testing.T) {
+	md := parseMessage(t, `syntax = "proto3";
+message Target {
+  oneof target {
+    string name = 1;
+    int32 id = 2;
+  }
+  int32 level = 3;
+}`, "Target")
+	for _, in := range []string{
+		`{"unknown": 1}`,
+		`{"level": "high"}`,
+		`{"level": 3000000000}`,
+		`{"target": {"name": "a", "id": 1}}`,
+		`{"target": {"level": 1}}`,
+	} {
+		obj, err := DecodeJSON([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := JSONToMessage(dynamic.NewMessage(md), obj); err == nil {
+			t.Errorf("Expected an error for %s", in)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package protofmt
+
+import (
+	"path/filepath"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// ParseProtoFile parses the proto file and its imports, which are resolved against the import paths.
+// Without import paths, the imports are resolved against the directory of the proto file
+func ParseProtoFile(file string, importPaths ...string) (*desc.FileDescriptor, error) {
+	if len(importPaths) == 0 {
+		importPaths = []string{filepath.Dir(file)}
+		file = filepath.Base(file)
+	}
+	p := protoparse.Parser{ImportPaths: importPaths}
+	fds, err := p.ParseFiles(file)
+	if err != nil {
+		return nil, err
+	}
+	return fds[0], nil
+}
@@ -0,0 +1,454 @@
+package wot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a difference between two revisions of a TD
+type ChangeKind string
+
+// Kinds of changes reported by Diff
+const (
+	AffordanceAdded        ChangeKind = "affordanceAdded"
+	AffordanceRemoved      ChangeKind = "affordanceRemoved"
+	AffordanceRenamed      ChangeKind = "affordanceRenamed"
+	AffordanceReclassified ChangeKind = "affordanceReclassified"
+	OperationAdded         ChangeKind = "operationAdded"
+	OperationRemoved       ChangeKind = "operationRemoved"
+	TypeChanged            ChangeKind = "typeChanged"
+	FieldAdded             ChangeKind = "fieldAdded"
+	FieldRemoved           ChangeKind = "fieldRemoved"
+	FieldRequired          ChangeKind = "fieldRequired"
+	EnumValueAdded         ChangeKind = "enumValueAdded"
+	EnumValueRemoved       ChangeKind = "enumValueRemoved"
+	AlternativeAdded       ChangeKind = "alternativeAdded"
+	AlternativeRemoved     ChangeKind = "alternativeRemoved"
+)
+
+// Change describes a difference between two revisions of a TD
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+
+	// JSON pointer (RFC 6901) to the changed value, in the old TD for removals and in the new TD otherwise
+	Pointer string `json:"pointer"`
+
+	// The change breaks consumers of the old TD
+	Breaking bool `json:"breaking"`
+
+	// Human-readable description of the change
+	Description string `json:"description"`
+}
+
+func (c Change) String() string {
+	compatibility := "compatible"
+	if c.Breaking {
+		compatibility = "BREAKING"
+	}
+	return fmt.Sprintf("%-10s %s: %s", compatibility, c.Pointer, c.Description)
+}
+
+// Changes are all differences between two revisions of a TD
+type Changes []Change
+
+// Breaking determines if any of the changes breaks consumers of the old TD
+func (c Changes) Breaking() bool {
+	for _, v := range c {
+		if v.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// direction tells whether consumers send a value (in), receive it (out) or both, which decides if a change of
+// its data schema is breaking: consumers must not send values the Thing does not accept anymore and
+// must not receive values they do not expect
+type direction struct {
+	in, out bool
+}
+
+// schemaSlot is a data schema of an affordance, relative to the pointer of the affordance
+type schemaSlot struct {
+	path string
+	ds   *DataSchema
+	dir  direction
+}
+
+// affordance is the comparable view on an interaction affordance of any class
+type affordance struct {
+	ops          []string
+	schemas      []schemaSlot
+	uriVariables map[string]DataSchema
+}
+
+// affordanceClasses are the names of the interaction affordance classes in a TD
+var affordanceClasses = []string{"properties", "actions", "events"}
+
+// escapePointer escapes a token of a JSON pointer
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// formOps returns the operation types of all forms
+func formOps(forms []Form) []string {
+	var ops []string
+	for _, f := range forms {
+		for _, op := range f.Ops() {
+			if !containsString(ops, op) {
+				ops = append(ops, op)
+			}
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// affordances returns the affordances of a class of the TD
+func affordances(td ThingDescription, class string) map[string]affordance {
+	m := map[string]affordance{}
+	switch class {
+	case "properties":
+		for k, v := range td.Properties {
+			ds := v.DataSchema
+			ops := formOps(v.Forms)
+			if len(ops) == 0 {
+				// default operations of a property without explicit operations
+				if !ds.WriteOnly {
+					ops = append(ops, "readproperty")
+				}
+				if !ds.ReadOnly {
+					ops = append(ops, "writeproperty")
+				}
+			}
+			if v.Observable && !containsString(ops, "observeproperty") {
+				ops = append(ops, "observeproperty")
+				sort.Strings(ops)
+			}
+			dir := direction{in: containsString(ops, "writeproperty"), out: containsString(ops, "readproperty")}
+			m[k] = affordance{ops: ops, schemas: []schemaSlot{{"", &ds, dir}}, uriVariables: v.UriVariables}
+		}
+	case "actions":
+		for k, v := range td.Actions {
+			m[k] = affordance{
+				ops:          formOps(v.Forms),
+				schemas:      []schemaSlot{{"/input", v.Input, direction{in: true}}, {"/output", v.Output, direction{out: true}}},
+				uriVariables: v.UriVariables,
+			}
+		}
+	case "events":
+		for k, v := range td.Events {
+			m[k] = affordance{
+				ops: formOps(v.Forms),
+				schemas: []schemaSlot{{"/subscription", v.Subscription, direction{in: true}}, {"/data", v.Data, direction{out: true}},
+					{"/cancellation", v.Cancellation, direction{in: true}}},
+				uriVariables: v.UriVariables,
+			}
+		}
+	}
+	return m
+}
+
+// signature serializes the data schemas of an affordance, affordances with the same signature are candidates
+// for a rename
+func (a affordance) signature() string {
+	schemas := []interface{}{a.ops, a.uriVariables}
+	for _, s := range a.schemas {
+		schemas = append(schemas, s.ds)
+	}
+	b, _ := json.Marshal(schemas)
+	return string(b)
+}
+
+// affordanceNames returns the names of the affordances in alphabetical order
+func affordanceNames(m map[string]affordance) []string {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Diff compares two revisions of a TD and reports the added, removed, renamed and reclassified affordances and the
+// changes of their operations and data schemas. A change is breaking if consumers of the old TD may fail with the
+// new TD. The changes are ordered by their pointers
+func Diff(old, new ThingDescription) Changes {
+	var changes Changes
+	oldClasses, newClasses := map[string]map[string]affordance{}, map[string]map[string]affordance{}
+	for _, class := range affordanceClasses {
+		oldClasses[class], newClasses[class] = affordances(old, class), affordances(new, class)
+	}
+	for _, class := range affordanceClasses {
+		oldAffs, newAffs := oldClasses[class], newClasses[class]
+		var removed, added []string
+		for _, k := range affordanceNames(oldAffs) {
+			pointer := "/" + class + "/" + escapePointer(k)
+			n, ok := newAffs[k]
+			if ok {
+				changes = append(changes, diffAffordance(pointer, oldAffs[k], n)...)
+				continue
+			}
+			reclassified := false
+			for _, other := range affordanceClasses {
+				if _, found := newClasses[other][k]; found && other != class {
+					changes = append(changes, Change{AffordanceReclassified, pointer, true,
+						fmt.Sprintf("%s is reclassified from %s to %s", k, class, other)})
+					reclassified = true
+				}
+			}
+			if !reclassified {
+				removed = append(removed, k)
+			}
+		}
+		for _, k := range affordanceNames(newAffs) {
+			if _, ok := oldAffs[k]; ok {
+				continue
+			}
+			reclassified := false
+			for _, other := range affordanceClasses {
+				if _, found := oldClasses[other][k]; found && other != class {
+					reclassified = true
+				}
+			}
+			if !reclassified {
+				added = append(added, k)
+			}
+		}
+		changes = append(changes, diffNames(class, removed, added, oldAffs, newAffs)...)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Pointer < changes[j].Pointer
+	})
+	return changes
+}
+
+// diffNames reports the removed and added affordances of a class, a removed affordance with the same data schemas as
+// exactly one added affordance is reported as renamed
+func diffNames(class string, removed, added []string, oldAffs, newAffs map[string]affordance) Changes {
+	var changes Changes
+	renamed := map[string]bool{}
+	for _, r := range removed {
+		var candidates []string
+		for _, a := range added {
+			if !renamed[a] && oldAffs[r].signature() == newAffs[a].signature() {
+				candidates = append(candidates, a)
+			}
+		}
+		pointer := "/" + class + "/" + escapePointer(r)
+		if len(candidates) == 1 {
+			renamed[candidates[0]] = true
+			changes = append(changes, Change{AffordanceRenamed, pointer, true,
+				fmt.Sprintf("%s is renamed to %s", r, candidates[0])})
+			continue
+		}
+		changes = append(changes, Change{AffordanceRemoved, pointer, true, r + " is removed"})
+	}
+	for _, a := range added {
+		if !renamed[a] {
+			changes = append(changes, Change{AffordanceAdded, "/" + class + "/" + escapePointer(a), false, a + " is added"})
+		}
+	}
+	return changes
+}
+
+// diffAffordance compares the operations, URI variables and data schemas of an affordance
+func diffAffordance(pointer string, old, new affordance) Changes {
+	var changes Changes
+	for _, op := range old.ops {
+		if !containsString(new.ops, op) {
+			changes = append(changes, Change{OperationRemoved, pointer + "/forms", true,
+				"the operation " + op + " is removed"})
+		}
+	}
+	for _, op := range new.ops {
+		if !containsString(old.ops, op) {
+			changes = append(changes, Change{OperationAdded, pointer + "/forms", false,
+				"the operation " + op + " is added"})
+		}
+	}
+	changes = append(changes, diffFields(pointer+"/uriVariables", func(n string) string { return "/" + escapePointer(n) },
+		old.uriVariables, new.uriVariables, nil, nil, direction{in: true})...)
+	for k, s := range old.schemas {
+		changes = append(changes, diffSchema(pointer+s.path, s.ds, new.schemas[k].ds, new.schemas[k].dir)...)
+	}
+	return changes
+}
+
+// diffSchema compares two data schemas at the pointer, the direction decides which changes are breaking
+func diffSchema(pointer string, old, new *DataSchema, dir direction) Changes {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return Changes{{FieldAdded, pointer, dir.in, "the data schema is added"}}
+	case new == nil:
+		return Changes{{FieldRemoved, pointer, true, "the data schema is removed"}}
+	}
+	if old.DataType != new.DataType || (old.OneOf == nil) != (new.OneOf == nil) {
+		return Changes{{TypeChanged, pointer, true, fmt.Sprintf("the type changed from %s to %s", typeName(old), typeName(new))}}
+	}
+	var changes Changes
+	changes = append(changes, diffEnum(pointer, old.Enum, new.Enum, dir)...)
+	changes = append(changes, diffAlternatives(pointer, old.OneOf, new.OneOf, dir)...)
+	if old.ArraySchema != nil || new.ArraySchema != nil {
+		changes = append(changes, diffSchema(pointer+"/items", items(old), items(new), dir)...)
+	}
+	var oldProps, newProps map[string]DataSchema
+	var oldRequired, newRequired []string
+	if old.ObjectSchema != nil {
+		oldProps, oldRequired = old.Properties, old.Required
+	}
+	if new.ObjectSchema != nil {
+		newProps, newRequired = new.Properties, new.Required
+	}
+	changes = append(changes, diffFields(pointer, func(n string) string { return "/properties/" + escapePointer(n) },
+		oldProps, newProps, oldRequired, newRequired, dir)...)
+	return changes
+}
+
+// typeName describes the type of a data schema
+func typeName(ds *DataSchema) string {
+	switch {
+	case ds.OneOf != nil:
+		return "oneOf"
+	case ds.DataType == "":
+		return "any"
+	}
+	return ds.DataType
+}
+
+func items(ds *DataSchema) *DataSchema {
+	if ds.ArraySchema == nil {
+		return nil
+	}
+	return ds.Items
+}
+
+// diffFields compares the fields of two objects, fieldPointer returns the pointer of a field relative to the object
+func diffFields(pointer string, fieldPointer func(string) string, old, new map[string]DataSchema, oldRequired,
+	newRequired []string, dir direction) Changes {
+	var changes Changes
+	names := map[string]bool{}
+	for k := range old {
+		names[k] = true
+	}
+	for k := range new {
+		names[k] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		o, inOld := old[k]
+		n, inNew := new[k]
+		p := pointer + fieldPointer(k)
+		required := containsString(newRequired, k)
+		switch {
+		case !inNew:
+			changes = append(changes, Change{FieldRemoved, p, true, "the field " + k + " is removed"})
+		case !inOld:
+			description := "the field " + k + " is added"
+			if required {
+				description = "the required field " + k + " is added"
+			}
+			changes = append(changes, Change{FieldAdded, p, dir.in && required, description})
+		default:
+			if required && !containsString(oldRequired, k) {
+				changes = append(changes, Change{FieldRequired, p, dir.in, "the field " + k + " is required"})
+			}
+			changes = append(changes, diffSchema(p, &o, &n, dir)...)
+		}
+	}
+	return changes
+}
+
+// diffEnum compares the allowed values, removed values break senders and added values break receivers
+func diffEnum(pointer string, old, new []interface{}, dir direction) Changes {
+	if old == nil || new == nil {
+		if old != nil {
+			return Changes{{EnumValueAdded, pointer, dir.out, "the values are not restricted anymore"}}
+		}
+		if new != nil {
+			return Changes{{EnumValueRemoved, pointer + "/enum", dir.in, "the values are restricted to the enum"}}
+		}
+		return nil
+	}
+	var changes Changes
+	for _, v := range old {
+		if !containsValue(new, v) {
+			changes = append(changes, Change{EnumValueRemoved, pointer + "/enum", dir.in,
+				fmt.Sprintf("the value %v is removed", v)})
+		}
+	}
+	for _, v := range new {
+		if !containsValue(old, v) {
+			changes = append(changes, Change{EnumValueAdded, pointer + "/enum", dir.out,
+				fmt.Sprintf("the value %v is added", v)})
+		}
+	}
+	return changes
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// alternativeKey identifies an alternative of a oneOf by its title, its type or its position
+func alternativeKey(ds DataSchema, k int) string {
+	switch {
+	case ds.Title != "":
+		return ds.Title
+	case ds.DataType != "":
+		return ds.DataType
+	}
+	return strconv.Itoa(k)
+}
+
+// diffAlternatives compares the alternatives of a oneOf, removed alternatives break senders and added
+// alternatives break receivers
+func diffAlternatives(pointer string, old, new []DataSchema, dir direction) Changes {
+	var changes Changes
+	newKeys := map[string]int{}
+	for k, v := range new {
+		newKeys[alternativeKey(v, k)] = k
+	}
+	oldKeys := map[string]bool{}
+	for k, v := range old {
+		key := alternativeKey(v, k)
+		oldKeys[key] = true
+		n, ok := newKeys[key]
+		if !ok {
+			changes = append(changes, Change{AlternativeRemoved, pointer + "/oneOf/" + strconv.Itoa(k), dir.in,
+				"the alternative " + key + " is removed"})
+			continue
+		}
+		changes = append(changes, diffSchema(pointer+"/oneOf/"+strconv.Itoa(n), &old[k], &new[n], dir)...)
+	}
+	for k, v := range new {
+		if key := alternativeKey(v, k); !oldKeys[key] {
+			changes = append(changes, Change{AlternativeAdded, pointer + "/oneOf/" + strconv.Itoa(k), dir.out,
+				"the alternative " + key + " is added"})
+		}
+	}
+	return changes
+}
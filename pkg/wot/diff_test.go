@@ -0,0 +1,116 @@
+package wot
+
+import (
+	"testing"
+)
+
+func objectSchema(properties map[string]DataSchema, required ...string) *DataSchema {
+	return &DataSchema{DataType: "object", ObjectSchema: &ObjectSchema{Properties: properties, Required: required}}
+}
+
+func diffTestThing() ThingDescription {
+	return ThingDescription{
+		Title: "Lamp",
+		Properties: map[string]PropertyAffordance{
+			"Brightness": {
+				InteractionAffordance: InteractionAffordance{Forms: []Form{{Op: []interface{}{"readproperty", "writeproperty"}}}},
+				DataSchema:            *objectSchema(map[string]DataSchema{"level": {DataType: "integer"}}),
+			},
+			"Mode": {
+				InteractionAffordance: InteractionAffordance{Forms: []Form{{Op: "readproperty"}}},
+				DataSchema: *objectSchema(map[string]DataSchema{
+					"mode": {DataType: "string", Enum: []interface{}{"OFF", "ON"}},
+				}),
+			},
+		},
+		Actions: map[string]ActionAffordance{
+			"Toggle": {
+				InteractionAffordance: InteractionAffordance{Forms: []Form{{Op: []string{"invokeaction"}}}},
+				Input:                 objectSchema(map[string]DataSchema{"room": {DataType: "string"}}),
+				Output:                objectSchema(map[string]DataSchema{"on": {DataType: "boolean"}}),
+			},
+			"Reset": {
+				InteractionAffordance: InteractionAffordance{Forms: []Form{{Op: []string{"invokeaction"}}}},
+				Input:                 objectSchema(map[string]DataSchema{"hard": {DataType: "boolean"}}),
+			},
+		},
+		Events: map[string]EventAffordance{
+			"Overheated": {
+				InteractionAffordance: InteractionAffordance{Forms: []Form{{Op: []string{"subscribeevent"}}}},
+				Data:                  objectSchema(map[string]DataSchema{"celsius": {DataType: "number"}}),
+			},
+		},
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	if changes := Diff(diffTestThing(), diffTestThing()); len(changes) != 0 {
+		t.Errorf("Expected no changes, but got %v", changes)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old, new := diffTestThing(), diffTestThing()
+	// the property Brightness becomes read-only and the level a number
+	brightness := new.Properties["Brightness"]
+	brightness.Forms = []Form{{Op: []string{"readproperty"}}}
+	brightness.DataSchema = *objectSchema(map[string]DataSchema{"level": {DataType: "number"}})
+	new.Properties["Brightness"] = brightness
+	// the property Mode gets a new value, which its readers do not know
+	mode := new.Properties["Mode"]
+	mode.DataSchema = *objectSchema(map[string]DataSchema{
+		"mode": {DataType: "string", Enum: []interface{}{"OFF", "ON", "AUTO"}},
+	})
+	new.Properties["Mode"] = mode
+	// the input of Toggle requires a new field and its output loses a field
+	toggle := new.Actions["Toggle"]
+	toggle.Input = objectSchema(map[string]DataSchema{"room": {DataType: "string"}, "id": {DataType: "integer"}}, "id")
+	toggle.Output = objectSchema(map[string]DataSchema{"state": {DataType: "string"}})
+	new.Actions["Toggle"] = toggle
+	// Reset is renamed and Overheated becomes a property
+	new.Actions["Restart"] = new.Actions["Reset"]
+	delete(new.Actions, "Reset")
+	new.Properties["Overheated"] = PropertyAffordance{DataSchema: DataSchema{DataType: "boolean"}}
+	delete(new.Events, "Overheated")
+
+	expected := Changes{
+		{OperationRemoved, "/properties/Brightness/forms", true, "the operation writeproperty is removed"},
+		{TypeChanged, "/properties/Brightness/properties/level", true, "the type changed from integer to number"},
+		{EnumValueAdded, "/properties/Mode/properties/mode/enum", true, "the value AUTO is added"},
+		{AffordanceRenamed, "/actions/Reset", true, "Reset is renamed to Restart"},
+		{FieldAdded, "/actions/Toggle/input/properties/id", true, "the required field id is added"},
+		{FieldRemoved, "/actions/Toggle/output/properties/on", true, "the field on is removed"},
+		{FieldAdded, "/actions/Toggle/output/properties/state", false, "the field state is added"},
+		{AffordanceReclassified, "/events/Overheated", true, "Overheated is reclassified from events to properties"},
+	}
+	changes := Diff(old, new)
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, but got %v", len(expected), changes)
+	}
+	index := map[string]Change{}
+	for _, c := range changes {
+		index[c.Pointer+c.Description] = c
+	}
+	for _, e := range expected {
+		if c, ok := index[e.Pointer+e.Description]; !ok || c != e {
+			t.Errorf("Expected the change %v, but got %v", e, changes)
+		}
+	}
+	if !changes.Breaking() {
+		t.Errorf("Expected the changes to be breaking")
+	}
+}
+
+func TestDiffCompatible(t *testing.T) {
+	old, new := diffTestThing(), diffTestThing()
+	// new optional input fields, new output fields and new affordances do not break consumers
+	toggle := new.Actions["Toggle"]
+	toggle.Input = objectSchema(map[string]DataSchema{"room": {DataType: "string"}, "fade": {DataType: "boolean"}})
+	toggle.Output = objectSchema(map[string]DataSchema{"on": {DataType: "boolean"}, "at": {DataType: "integer"}}, "at")
+	new.Actions["Toggle"] = toggle
+	new.Events["Dimmed"] = EventAffordance{Data: objectSchema(map[string]DataSchema{"level": {DataType: "integer"}})}
+	changes := Diff(old, new)
+	if len(changes) != 3 || changes.Breaking() {
+		t.Errorf("Expected three compatible changes, but got %v", changes)
+	}
+}
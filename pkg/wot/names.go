@@ -0,0 +1,56 @@
+package wot
+
+import "sort"
+
+// PropertyNames returns the names of the properties in alphabetical order
+func (td ThingDescription) PropertyNames() []string {
+	names := make([]string, 0, len(td.Properties))
+	for k := range td.Properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActionNames returns the names of the actions in alphabetical order
+func (td ThingDescription) ActionNames() []string {
+	names := make([]string, 0, len(td.Actions))
+	for k := range td.Actions {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EventNames returns the names of the events in alphabetical order
+func (td ThingDescription) EventNames() []string {
+	names := make([]string, 0, len(td.Events))
+	for k := range td.Events {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SecurityDefinitionNames returns the names of the security definitions in alphabetical order
+func (td ThingDescription) SecurityDefinitionNames() []string {
+	names := make([]string, 0, len(td.SecurityDefinitions))
+	for k := range td.SecurityDefinitions {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PropertyNames returns the names of the members of the object in alphabetical order
+func (s *ObjectSchema) PropertyNames() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,449 @@
+// Package wot provides the information model of a W3C Web of Things Thing Description (TD)
+// cf. https://www.w3.org/TR/wot-thing-description11/
+package wot
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// MediaTypeThingDescription is the media type of a serialized TD
+const MediaTypeThingDescription = "application/td+json"
+
+// ContextV11 is the JSON-LD context of TD 1.1, which includes the terms of Thing Models (prefix tm)
+const ContextV11 = "https://www.w3.org/2022/wot/td/v1.1"
+
+// TypeThingModel is the @type which marks a document as Thing Model
+// cf. https://www.w3.org/TR/wot-thing-description11/#thing-model
+const TypeThingModel = "tm:ThingModel"
+
+// ThingDescription describes the metadata and interfaces of a Thing
+// cf. https://www.w3.org/TR/wot-thing-description11/#thing
+type ThingDescription struct {
+	// JSON-LD keyword to define short-hand names called terms that are used throughout a TD document
+	Context interface{} `json:"@context"`
+
+	// JSON-LD keyword to label the object with semantic tags (or types)
+	Type interface{} `json:"@type,omitempty"`
+
+	// Identifier of the Thing in form of a URI
+	ID string `json:"id,omitempty"`
+
+	// Human-readable title based on a default language
+	Title string `json:"title"`
+
+	// Multi-language human-readable titles
+	Titles map[string]string `json:"titles,omitempty"`
+
+	// Human-readable information based on a default language
+	Description string `json:"description,omitempty"`
+
+	// Multi-language human-readable information
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+
+	// Version information
+	Version *VersionInfo `json:"version,omitempty"`
+
+	// Time when the TD instance was created
+	Created *time.Time `json:"created,omitempty"`
+
+	// Time when the TD instance was last modified
+	Modified *time.Time `json:"modified,omitempty"`
+
+	// Information about the TD maintainer as URI scheme (e.g., mailto, tel, https)
+	Support string `json:"support,omitempty"`
+
+	// Base URI that is used for all relative URI references throughout a TD document
+	Base string `json:"base,omitempty"`
+
+	// All Property-based Interaction Affordances of the Thing
+	Properties map[string]PropertyAffordance `json:"properties,omitempty"`
+
+	// All Action-based Interaction Affordances of the Thing
+	Actions map[string]ActionAffordance `json:"actions,omitempty"`
+
+	// All Event-based Interaction Affordances of the Thing
+	Events map[string]EventAffordance `json:"events,omitempty"`
+
+	// Web links to arbitrary resources that relate to the Thing
+	Links []Link `json:"links,omitempty"`
+
+	// Forms of operations on the Thing level, e.g. readallproperties
+	Forms []Form `json:"forms,omitempty"`
+
+	// Set of security definition names, chosen from those defined in securityDefinitions
+	Security interface{} `json:"security"`
+
+	// Set of named security configurations
+	SecurityDefinitions map[string]SecurityScheme `json:"securityDefinitions"`
+
+	// Set of named data schemas, which can be referenced by the additional responses of forms
+	SchemaDefinitions map[string]DataSchema `json:"schemaDefinitions,omitempty"`
+}
+
+// VersionInfo provides version information about the TD document
+type VersionInfo struct {
+	// Version indicator of this TD instance
+	Instance string `json:"instance,omitempty"`
+
+	// Version indicator of the underlying Thing Model
+	Model string `json:"model,omitempty"`
+}
+
+// InteractionAffordance holds the metadata shared by properties, actions and events
+// cf. https://www.w3.org/TR/wot-thing-description11/#interactionaffordance
+type InteractionAffordance struct {
+	// JSON-LD keyword to label the object with semantic tags (or types)
+	Type interface{} `json:"@type,omitempty"`
+
+	// Human-readable title based on a default language
+	Title string `json:"title,omitempty"`
+
+	// Multi-language human-readable titles
+	Titles map[string]string `json:"titles,omitempty"`
+
+	// Human-readable information based on a default language
+	Description string `json:"description,omitempty"`
+
+	// Multi-language human-readable information
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+
+	// Form hypermedia controls that describe how an operation can be performed
+	Forms []Form `json:"forms"`
+
+	// URI template variables as collection based on DataSchema declarations
+	UriVariables map[string]DataSchema `json:"uriVariables,omitempty"`
+}
+
+// PropertyAffordance exposes state of the Thing
+type PropertyAffordance struct {
+	InteractionAffordance
+
+	// DataSchema of the property, it is serialized inline with the terms of the affordance
+	DataSchema DataSchema `json:"-"`
+
+	// Signals if the Thing pushes the new state after a change
+	Observable bool `json:"observable,omitempty"`
+}
+
+// MarshalJSON serializes the DataSchema inline with the property, where the terms shared by the interaction affordance
+// and the DataSchema (e.g. title and description) are taken from the interaction affordance if they are set
+func (p PropertyAffordance) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{}
+	for _, v := range []interface{}{p.DataSchema, p.InteractionAffordance} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		if err := d.Decode(&m); err != nil {
+			return nil, err
+		}
+	}
+	if p.Observable {
+		m["observable"] = true
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON reads the terms of the interaction affordance and the DataSchema of a property
+func (p *PropertyAffordance) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.InteractionAffordance); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &p.DataSchema); err != nil {
+		return err
+	}
+	var o struct {
+		Observable bool `json:"observable"`
+	}
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+	p.Observable = o.Observable
+	return nil
+}
+
+// ActionAffordance allows to invoke a function of the Thing
+type ActionAffordance struct {
+	InteractionAffordance
+
+	// DataSchema of the input of the action
+	Input *DataSchema `json:"input,omitempty"`
+
+	// DataSchema of the output of the action
+	Output *DataSchema `json:"output,omitempty"`
+
+	// Signals that no internal state is changed when invoking the action
+	Safe bool `json:"safe,omitempty"`
+
+	// Signals that the action can be called repeatedly with the same result
+	Idempotent bool `json:"idempotent,omitempty"`
+
+	// Signals if the action is synchronous, i.e. the response contains the result of the action (false: asynchronous)
+	Synchronous *bool `json:"synchronous,omitempty"`
+}
+
+// EventAffordance describes an event source, which asynchronously pushes event data to Consumers
+type EventAffordance struct {
+	InteractionAffordance
+
+	// Data that needs to be passed upon subscription
+	Subscription *DataSchema `json:"subscription,omitempty"`
+
+	// DataSchema of the event instance messages pushed by the Thing
+	Data *DataSchema `json:"data,omitempty"`
+
+	// Data that needs to be passed to cancel a subscription
+	Cancellation *DataSchema `json:"cancellation,omitempty"`
+}
+
+// Form describes how to perform an operation on an interaction affordance
+// cf. https://www.w3.org/TR/wot-thing-description11/#form
+type Form struct {
+	// Operation types, e.g. readproperty, invokeaction or subscribeevent
+	Op interface{} `json:"op"`
+
+	// Target IRI of the form
+	Href string `json:"href"`
+
+	// Content type of the payload
+	ContentType string `json:"contentType"`
+
+	// Content coding of the payload, e.g. gzip
+	ContentCoding string `json:"contentCoding,omitempty"`
+
+	// Mechanism by which an interaction is accomplished, e.g. longpoll, websub or sse
+	SubProtocol string `json:"subprotocol,omitempty"`
+
+	// HTTP method of the request, e.g. GET, cf. https://www.w3.org/TR/wot-binding-templates/ (HTTP Vocabulary)
+	MethodName string `json:"htv:methodName,omitempty"`
+
+	// Set of security definition names which must be satisfied for the form
+	Security interface{} `json:"security,omitempty"`
+
+	// Set of authorization scope identifiers
+	Scopes interface{} `json:"scopes,omitempty"`
+
+	// Metadata of the expected response message
+	Response *ExpectedResponse `json:"response,omitempty"`
+
+	// Metadata of further responses, e.g. error responses
+	AdditionalResponses []AdditionalExpectedResponse `json:"additionalResponses,omitempty"`
+}
+
+// Ops returns the operation types of the form, which are given as a single string or as an array
+func (f Form) Ops() []string {
+	switch v := f.Op.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var ops []string
+		for _, op := range v {
+			if s, ok := op.(string); ok {
+				ops = append(ops, s)
+			}
+		}
+		return ops
+	}
+	return nil
+}
+
+// ExpectedResponse holds the communication metadata of the response message
+type ExpectedResponse struct {
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// AdditionalExpectedResponse describes a response which differs from the expected response, e.g. an error
+type AdditionalExpectedResponse struct {
+	// Signals if the response is a successful result of the operation
+	Success bool `json:"success"`
+
+	// Content type of the response, by default the content type of the form
+	ContentType string `json:"contentType,omitempty"`
+
+	// Name of the data schema in the schemaDefinitions of the TD, which describes the response
+	Schema string `json:"schema,omitempty"`
+}
+
+// Link is a Web link to a resource related to the Thing
+type Link struct {
+	// Target IRI of the link
+	Href string `json:"href"`
+
+	// Media type of the link target
+	Type string `json:"type,omitempty"`
+
+	// Link relation type
+	Rel string `json:"rel,omitempty"`
+
+	// Overrides the link context (by default the Thing itself)
+	Anchor string `json:"anchor,omitempty"`
+}
+
+// SecurityScheme configures a security mechanism, the fields apply depending on the scheme
+// cf. https://www.w3.org/TR/wot-thing-description11/#sec-security-vocabulary-definition
+type SecurityScheme struct {
+	// JSON-LD keyword to label the object with semantic tags (or types)
+	Type interface{} `json:"@type,omitempty"`
+
+	// Identification of the security mechanism, e.g. nosec, basic, digest, bearer, psk, oauth2 or apikey
+	Scheme string `json:"scheme"`
+
+	// Human-readable information based on a default language
+	Description string `json:"description,omitempty"`
+
+	// Multi-language human-readable information
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+
+	// URI of the proxy server this security configuration provides access to
+	Proxy string `json:"proxy,omitempty"`
+
+	// Location of the security authentication information (header, query, body, cookie or uri)
+	In string `json:"in,omitempty"`
+
+	// Name for query, header, cookie, or uri parameters
+	Name string `json:"name,omitempty"`
+
+	// Quality of protection (digest)
+	Qop string `json:"qop,omitempty"`
+
+	// URI of the authorization server (bearer, oauth2)
+	Authorization string `json:"authorization,omitempty"`
+
+	// Encoding, encryption, or digest algorithm (bearer)
+	Alg string `json:"alg,omitempty"`
+
+	// Format of the security authentication information (bearer)
+	Format string `json:"format,omitempty"`
+
+	// Identifier providing information which can be used for selection or confirmation (psk)
+	Identity string `json:"identity,omitempty"`
+
+	// URI of the token server (oauth2)
+	Token string `json:"token,omitempty"`
+
+	// URI of the refresh server (oauth2)
+	Refresh string `json:"refresh,omitempty"`
+
+	// Set of authorization scope identifiers (oauth2)
+	Scopes interface{} `json:"scopes,omitempty"`
+
+	// Authorization flow (oauth2)
+	Flow string `json:"flow,omitempty"`
+}
+
+// DataSchema describes the data format used, it is based on a subset of JSON Schema
+// cf. https://www.w3.org/TR/wot-thing-description11/#dataschema
+type DataSchema struct {
+	// JSON-LD keyword to label the object with semantic tags (or types)
+	Type interface{} `json:"@type,omitempty"`
+
+	// Human-readable title based on a default language
+	Title string `json:"title,omitempty"`
+
+	// Multi-language human-readable titles
+	Titles map[string]string `json:"titles,omitempty"`
+
+	// Human-readable information based on a default language
+	Description string `json:"description,omitempty"`
+
+	// Multi-language human-readable information
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+
+	// Constant value
+	Const interface{} `json:"const,omitempty"`
+
+	// Default value which is assumed if the value is not given
+	Default interface{} `json:"default,omitempty"`
+
+	// Unit of the value
+	Unit string `json:"unit,omitempty"`
+
+	// Data is valid against exactly one of the schemas
+	OneOf []DataSchema `json:"oneOf,omitempty"`
+
+	// Restricted set of values
+	Enum []interface{} `json:"enum,omitempty"`
+
+	// Hint that the value is read only
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// Hint that the value is write only
+	WriteOnly bool `json:"writeOnly,omitempty"`
+
+	// Validation based on a format pattern, e.g. date-time, email or uri
+	Format string `json:"format,omitempty"`
+
+	// JSON-based data type (boolean, integer, number, string, object, array, or null)
+	DataType string `json:"type,omitempty"`
+
+	*ArraySchema
+
+	*NumberSchema
+
+	*StringSchema
+
+	*ObjectSchema
+}
+
+// ArraySchema holds the metadata of data of type array
+type ArraySchema struct {
+	// DataSchema of the items
+	Items *DataSchema `json:"items,omitempty"`
+
+	// Minimum number of items
+	MinItems *int `json:"minItems,omitempty"`
+
+	// Maximum number of items
+	MaxItems *int `json:"maxItems,omitempty"`
+}
+
+// NumberSchema holds the metadata of data of type number or integer
+type NumberSchema struct {
+	// Minimum value (inclusive)
+	Minimum *float64 `json:"minimum,omitempty"`
+
+	// Minimum value (exclusive)
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+
+	// Maximum value (inclusive)
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Maximum value (exclusive)
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+	// The value must be a multiple of MultipleOf
+	MultipleOf *float64 `json:"multipleOf,omitempty"`
+}
+
+// StringSchema holds the metadata of data of type string
+type StringSchema struct {
+	// Minimum length of the string
+	MinLength *int `json:"minLength,omitempty"`
+
+	// Maximum length of the string
+	MaxLength *int `json:"maxLength,omitempty"`
+
+	// Regular expression the string must match
+	Pattern string `json:"pattern,omitempty"`
+
+	// Encoding used to store the contents, e.g. base64
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+
+	// Media type of the contents
+	ContentMediaType string `json:"contentMediaType,omitempty"`
+}
+
+// ObjectSchema holds the metadata of data of type object
+type ObjectSchema struct {
+	// Nested DataSchemas of the object members
+	Properties map[string]DataSchema `json:"properties,omitempty"`
+
+	// Members which must be present
+	Required []string `json:"required,omitempty"`
+}
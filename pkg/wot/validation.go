@@ -0,0 +1,94 @@
+package wot
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// tdSchema is the JSON Schema of TD 1.1 for validating TD instances
+//
+//go:embed td-json-schema-validation.json
+var tdSchema []byte
+
+var (
+	schema     *gojsonschema.Schema
+	schemaErr  error
+	schemaOnce sync.Once
+)
+
+// loadSchema compiles the embedded TD JSON Schema once
+func loadSchema() (*gojsonschema.Schema, error) {
+	schemaOnce.Do(func() {
+		schema, schemaErr = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(tdSchema))
+	})
+	return schema, schemaErr
+}
+
+// ValidationError describes a violation of the TD JSON Schema
+type ValidationError struct {
+	// JSON pointer (RFC 6901) to the invalid value in the TD, the empty string points to the TD itself
+	Pointer string `json:"pointer"`
+
+	// Human-readable description of the violation
+	Description string `json:"description"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.displayPointer(), e.Description)
+}
+
+func (e ValidationError) displayPointer() string {
+	if e.Pointer == "" {
+		return "/"
+	}
+	return e.Pointer
+}
+
+// ValidationErrors are all violations of the TD JSON Schema found in a TD
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for k, v := range e {
+		msgs[k] = v.Error()
+	}
+	return "invalid Thing Description: " + strings.Join(msgs, "; ")
+}
+
+// jsonPointer converts the context of a gojsonschema result error into a JSON pointer
+func jsonPointer(ctx *gojsonschema.JsonContext) string {
+	// the context is joined by a delimiter which cannot be part of the keys of a TD,
+	// the first token is the root of the document
+	tokens := strings.Split(ctx.String("\x00"), "\x00")[1:]
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/" + escaper.Replace(t))
+	}
+	return b.String()
+}
+
+// Validate validates the serialized TD against the TD JSON Schema.
+// The returned ValidationErrors are empty if the TD is valid, the error is set if the TD could not be validated at all
+func Validate(td []byte) (ValidationErrors, error) {
+	s, err := loadSchema()
+	if err != nil {
+		return nil, fmt.Errorf("error loading the TD schema: %s", err)
+	}
+	result, err := s.Validate(gojsonschema.NewBytesLoader(td))
+	if err != nil {
+		return nil, err
+	}
+	if result.Valid() {
+		return nil, nil
+	}
+	var issues ValidationErrors
+	for _, re := range result.Errors() {
+		issues = append(issues, ValidationError{Pointer: jsonPointer(re.Context()), Description: re.Description()})
+	}
+	return issues, nil
+}
@@ -0,0 +1,74 @@
+package wot
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func validThingDescription() ThingDescription {
+	return ThingDescription{
+		Context: ContextV11,
+		Title:   "Test",
+		Base:    "http://127.0.0.1:50051/",
+		Actions: map[string]ActionAffordance{
+			"Reset": {
+				InteractionAffordance: InteractionAffordance{
+					Forms: []Form{{Op: []string{"invokeaction"}, Href: "Test/Reset", ContentType: "application/grpc+proto"}},
+				},
+				Input: &DataSchema{DataType: "object"},
+			},
+		},
+		Security:            "nosec_sc",
+		SecurityDefinitions: map[string]SecurityScheme{"nosec_sc": {Scheme: "nosec"}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	td, _ := json.Marshal(validThingDescription())
+	issues, err := Validate(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected a valid TD, but got %v", issues)
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	invalid := validThingDescription()
+	invalid.Context = nil
+	invalid.Actions["Reset"].Forms[0].Op = []string{"readproperty"}
+	td, _ := json.Marshal(invalid)
+	issues, err := Validate(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pointers := map[string]bool{}
+	for _, v := range issues {
+		pointers[v.Pointer] = true
+	}
+	for _, p := range []string{"/@context", "/actions/Reset/forms/0/op"} {
+		if !pointers[p] {
+			t.Errorf("Expected an error at %v, but got %v", p, issues)
+		}
+	}
+	if _, err := Validate([]byte("{")); err == nil {
+		t.Errorf("Expected an error for malformed JSON")
+	}
+}
+
+func TestJsonPointerEscaping(t *testing.T) {
+	td := validThingDescription()
+	td.Properties = map[string]PropertyAffordance{
+		"a/b~c": {InteractionAffordance: InteractionAffordance{Forms: []Form{{Op: "invokeaction", Href: "Test/a"}}}},
+	}
+	b, _ := json.Marshal(td)
+	issues, err := Validate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) == 0 || !strings.HasPrefix(issues[0].Pointer, "/properties/a~1b~0c/") {
+		t.Errorf("Expected an escaped pointer to the property a/b~c, but got %v", issues)
+	}
+}
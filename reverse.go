@@ -0,0 +1,668 @@
+package grpcwot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// The reverse generation derives a proto file from a TD, such that the forward generation with the derived
+// classification config results in the same affordances. Properties are served by Get/Set RPCs, actions by unary RPCs
+// and events by server streaming RPCs. Annotations of the data schemas which can be expressed as comment directives
+// are kept, other constraints such as required fields or the length of strings are lost
+
+// emptyMessage is the message of requests and responses without payload
+const emptyMessage = "Empty"
+
+// identifierPattern matches identifiers of the proto language
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// protoIdentifier converts a name into an identifier, invalid characters are replaced by underscores
+func protoIdentifier(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	id := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name)
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "_" + id
+	}
+	return id
+}
+
+// camelCase converts a name into an identifier in CamelCase, e.g. switched_at into SwitchedAt
+func camelCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range protoIdentifier(name) {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+		}
+		b.WriteRune(r)
+		upper = false
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// uniqueName returns the name or the name with the smallest numeric suffix which is not in the scope yet,
+// the returned name is added to the scope
+func uniqueName(scope map[string]bool, name string) string {
+	unique := name
+	for k := 1; scope[unique]; k++ {
+		unique = name + strconv.Itoa(k)
+	}
+	scope[unique] = true
+	return unique
+}
+
+// directiveValue removes the characters from a value which would end the directive or its quotes
+func directiveValue(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\"", "'").Replace(s)
+}
+
+// reverser derives the proto file of a TD
+type reverser struct {
+	messages []string                   // top-level messages in the order they are derived
+	names    map[string]bool            // names of the top-level messages
+	empty    bool                       // the Empty message is used
+	orders   map[string][]string        // member names of the JSON objects of the TD per JSON pointer
+	previous map[string]*messageNumbers // field numbers of the previously derived proto file per full message name
+}
+
+// messageNumbers holds the field numbers and reserved numbers of a message of a previously derived proto file
+type messageNumbers struct {
+	fields   map[string]int
+	reserved []proto.Range
+}
+
+// pointerEscaper escapes the reference tokens of a JSON pointer
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// childPointer returns the JSON pointer of a member of the value at the pointer. The empty pointer stands for values
+// which are not part of the TD, e.g. the wrappers of data schemas which are no objects, and has no members
+func childPointer(pointer string, tokens ...string) string {
+	if pointer == "" {
+		return ""
+	}
+	for _, t := range tokens {
+		pointer += "/" + pointerEscaper.Replace(t)
+	}
+	return pointer
+}
+
+// memberOrders records the member names of each JSON object of the document in the order of the document, as the
+// maps of the decoded TD do not keep the order chosen by its author
+func memberOrders(data []byte) (map[string][]string, error) {
+	orders := map[string][]string{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var read func(pointer string) error
+	read = func(pointer string) error {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'):
+			keys := []string{}
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				keys = append(keys, k.(string))
+				if err := read(pointer + "/" + pointerEscaper.Replace(k.(string))); err != nil {
+					return err
+				}
+			}
+			orders[pointer] = keys
+		case json.Delim('['):
+			for k := 0; dec.More(); k++ {
+				if err := read(pointer + "/" + strconv.Itoa(k)); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+		// the closing delimiter
+		_, err = dec.Token()
+		return err
+	}
+	return orders, read("")
+}
+
+// previousNumbers reads the field numbers of the messages of a previously derived proto file, so deriving the proto
+// file of a revised TD keeps the numbers of its fields. Without the file, there are no previous numbers
+func previousNumbers(file string) (map[string]*messageNumbers, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	definition, err := proto.NewParser(f).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("the previous proto file %s cannot be parsed: %w", file, err)
+	}
+	numbers := map[string]*messageNumbers{}
+	proto.Walk(definition, proto.WithMessage(func(m *proto.Message) {
+		n := &messageNumbers{fields: map[string]int{}}
+		for _, e := range m.Elements {
+			switch v := e.(type) {
+			case *proto.NormalField:
+				n.fields[v.Name] = v.Sequence
+			case *proto.MapField:
+				n.fields[v.Name] = v.Sequence
+			case *proto.Oneof:
+				for _, o := range v.Elements {
+					if f, ok := o.(*proto.OneOfField); ok {
+						n.fields[f.Name] = f.Sequence
+					}
+				}
+			case *proto.Reserved:
+				n.reserved = append(n.reserved, v.Ranges...)
+			}
+		}
+		numbers[getFullMessageName(m)] = n
+	}))
+	return numbers, nil
+}
+
+// fieldNumbers assigns the numbers to the fields of a message. Fields of the previously derived message keep their
+// numbers and new fields get the next number which was never used by the message, the numbers of removed fields are
+// reserved. Without a previous message, the fields are numbered in order
+type fieldNumbers struct {
+	previous *messageNumbers
+	next     int
+	kept     map[string]bool
+}
+
+// newFieldNumbers starts numbering the fields of the message with the full name
+func (r *reverser) newFieldNumbers(fullName string) *fieldNumbers {
+	n := &fieldNumbers{previous: r.previous[fullName], next: 1, kept: map[string]bool{}}
+	if n.previous == nil {
+		return n
+	}
+	for _, k := range n.previous.fields {
+		if k >= n.next {
+			n.next = k + 1
+		}
+	}
+	for _, rg := range n.previous.reserved {
+		if !rg.Max && rg.To >= n.next {
+			n.next = rg.To + 1
+		}
+	}
+	return n
+}
+
+// number returns the number of the field
+func (n *fieldNumbers) number(name string) int {
+	if n.previous != nil {
+		if k, ok := n.previous.fields[name]; ok && !n.kept[name] {
+			n.kept[name] = true
+			return k
+		}
+	}
+	n.next++
+	return n.next - 1
+}
+
+// reserved returns the reserved numbers of the previous message and the numbers of its removed fields
+func (n *fieldNumbers) reserved() []string {
+	if n.previous == nil {
+		return nil
+	}
+	ranges := append([]proto.Range{}, n.previous.reserved...)
+	for name, k := range n.previous.fields {
+		if !n.kept[name] {
+			ranges = append(ranges, proto.Range{From: k, To: k})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From < ranges[j].From })
+	var reserved []string
+	for _, rg := range ranges {
+		reserved = append(reserved, rg.SourceRepresentation())
+	}
+	return reserved
+}
+
+// memberNames returns the names of the properties of an object schema in the order of the members object at the
+// JSON pointer, names which are not found there follow in alphabetical order
+func (r *reverser) memberNames(members string, properties map[string]wot.DataSchema) []string {
+	var keys []string
+	ordered := map[string]bool{}
+	if members != "" {
+		for _, k := range r.orders[members] {
+			if _, ok := properties[k]; ok && !ordered[k] {
+				keys = append(keys, k)
+				ordered[k] = true
+			}
+		}
+	}
+	var rest []string
+	for k := range properties {
+		if !ordered[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// emptyMessage returns the name of the message without fields
+func (r *reverser) emptyMessage() string {
+	r.empty = true
+	return emptyMessage
+}
+
+// message derives a top-level message from the data schema, whose properties are found at the JSON pointer members.
+// Data schemas which are no objects are wrapped into a message with the field value. Without data schema, the
+// message is Empty
+func (r *reverser) message(name string, ds *wot.DataSchema, members string) string {
+	if ds == nil {
+		return r.emptyMessage()
+	}
+	if ds.DataType != "object" {
+		ds = &wot.DataSchema{
+			DataType:     "object",
+			ObjectSchema: &wot.ObjectSchema{Properties: map[string]wot.DataSchema{"value": *ds}},
+		}
+		members = ""
+	}
+	name = uniqueName(r.names, camelCase(name))
+	var b strings.Builder
+	r.writeMessage(&b, "", name, *ds, members, "")
+	r.messages = append(r.messages, b.String())
+	return name
+}
+
+// writeMessage writes the message with the fields of the object schema, nested objects and enums are nested
+// declarations of the message. The fields follow the order of the members of the TD at the JSON pointer members.
+// Their numbers are kept from the previously derived proto file, so consumers of the previous revision can still
+// decode the messages of the revised TD
+func (r *reverser) writeMessage(b *strings.Builder, parent, name string, ds wot.DataSchema, members, indent string) {
+	fullName := name
+	if parent != "" {
+		fullName = parent + "." + name
+	}
+	var keys []string
+	if ds.ObjectSchema != nil {
+		keys = r.memberNames(members, ds.Properties)
+	}
+	// the fields, nested declarations and enum values share the scope of the message
+	scope := map[string]bool{}
+	for _, k := range keys {
+		scope[protoIdentifier(k)] = true
+	}
+	var fields, nested strings.Builder
+	numbers := r.newFieldNumbers(fullName)
+	for _, k := range keys {
+		p := ds.Properties[k]
+		name := protoIdentifier(k)
+		pointer := childPointer(members, k)
+		if alternatives, ok := oneofAlternatives(p); ok {
+			fmt.Fprintf(&fields, "%s  oneof %s {\n", indent, name)
+			for i, a := range alternatives {
+				alt := a.Properties[a.Title]
+				altPointer := childPointer(pointer, "oneOf", strconv.Itoa(i), "properties", a.Title)
+				typ, _ := r.fieldType(&nested, scope, fullName, protoIdentifier(a.Title), alt, altPointer, indent+"  ")
+				writeDirectives(&fields, alt, alt, indent+"    ")
+				fmt.Fprintf(&fields, "%s    %s %s = %d;\n", indent, typ, protoIdentifier(a.Title), numbers.number(protoIdentifier(a.Title)))
+			}
+			fmt.Fprintf(&fields, "%s  }\n", indent)
+			continue
+		}
+		label, inner := "", p
+		if n, ok := nullableValue(p); ok {
+			label, inner = "optional ", n
+			pointer = childPointer(pointer, "oneOf", "0")
+		}
+		typ, repeated := r.fieldType(&nested, scope, fullName, name, inner, pointer, indent+"  ")
+		if repeated {
+			label = "repeated "
+			if inner.Items != nil {
+				inner = *inner.Items
+			}
+		}
+		writeDirectives(&fields, p, inner, indent+"  ")
+		fmt.Fprintf(&fields, "%s  %s%s %s = %d;\n", indent, label, typ, name, numbers.number(name))
+	}
+	if reserved := numbers.reserved(); len(reserved) != 0 {
+		fmt.Fprintf(&fields, "%s  reserved %s;\n", indent, strings.Join(reserved, ", "))
+	}
+	if fields.Len() == 0 && nested.Len() == 0 {
+		fmt.Fprintf(b, "%smessage %s {}\n", indent, name)
+		return
+	}
+	fmt.Fprintf(b, "%smessage %s {\n%s%s%s}\n", indent, name, nested.String(), fields.String(), indent)
+}
+
+// fieldType returns the type of a field of the message parent with the data schema at the JSON pointer, nested
+// messages and enums are written to nested. Arrays are repeated fields of the type of their items
+func (r *reverser) fieldType(nested *strings.Builder, scope map[string]bool, parent, name string, ds wot.DataSchema, pointer, indent string) (string, bool) {
+	if values, ok := enumValues(scope, ds); ok {
+		enum := uniqueName(scope, camelCase(name))
+		fmt.Fprintf(nested, "%senum %s {\n", indent, enum)
+		for k, v := range values {
+			scope[v] = true
+			fmt.Fprintf(nested, "%s  %s = %d;\n", indent, v, k)
+		}
+		fmt.Fprintf(nested, "%s}\n", indent)
+		return enum, false
+	}
+	switch ds.DataType {
+	case "boolean":
+		return "bool", false
+	case "integer":
+		if ds.NumberSchema != nil && ds.Minimum != nil && ds.Maximum != nil &&
+			*ds.Minimum >= math.MinInt32 && *ds.Maximum <= math.MaxInt32 {
+			return "int32", false
+		}
+		return "int64", false
+	case "number":
+		return "double", false
+	case "string":
+		if ds.StringSchema != nil && ds.ContentEncoding == "base64" {
+			return "bytes", false
+		}
+		return "string", false
+	case "array":
+		if ds.ArraySchema == nil || ds.Items == nil {
+			return "string", true
+		}
+		if ds.Items.DataType == "array" {
+			// arrays of arrays are repeated messages holding the inner array
+			item := uniqueName(scope, camelCase(name)+"Item")
+			r.writeMessage(nested, parent, item, wot.DataSchema{
+				DataType:     "object",
+				ObjectSchema: &wot.ObjectSchema{Properties: map[string]wot.DataSchema{"values": *ds.Items}},
+			}, "", indent)
+			return item, true
+		}
+		typ, _ := r.fieldType(nested, scope, parent, name, *ds.Items, childPointer(pointer, "items"), indent)
+		return typ, true
+	case "object":
+		message := uniqueName(scope, camelCase(name))
+		r.writeMessage(nested, parent, message, ds, childPointer(pointer, "properties"), indent)
+		return message, false
+	}
+	return "string", false
+}
+
+// enumValues returns the values of an enum of strings, if they can be declared as values of a proto enum in the scope
+func enumValues(scope map[string]bool, ds wot.DataSchema) ([]string, bool) {
+	if len(ds.Enum) == 0 || ds.DataType != "string" && ds.DataType != "" {
+		return nil, false
+	}
+	values := make([]string, len(ds.Enum))
+	declared := map[string]bool{}
+	for k, e := range ds.Enum {
+		v, ok := e.(string)
+		if !ok || !identifierPattern.MatchString(v) || scope[v] || declared[v] {
+			return nil, false
+		}
+		values[k] = v
+		declared[v] = true
+	}
+	return values, true
+}
+
+// nullableValue returns the data schema of a nullable value, which is a proto3 optional field
+func nullableValue(ds wot.DataSchema) (wot.DataSchema, bool) {
+	if len(ds.OneOf) != 2 || ds.OneOf[1].DataType != "null" || ds.OneOf[0].DataType == "null" {
+		return ds, false
+	}
+	value := ds.OneOf[0]
+	value.Title, value.Description = ds.Title, ds.Description
+	return value, true
+}
+
+// oneofAlternatives returns the alternatives of a oneof, which are objects titled by the name of their only property
+func oneofAlternatives(ds wot.DataSchema) ([]wot.DataSchema, bool) {
+	if len(ds.OneOf) == 0 {
+		return nil, false
+	}
+	for _, a := range ds.OneOf {
+		if a.ObjectSchema == nil || len(a.Properties) != 1 {
+			return nil, false
+		}
+		// repeated fields cannot be alternatives of a oneof
+		if p, ok := a.Properties[a.Title]; !ok || p.DataType == "array" {
+			return nil, false
+		}
+	}
+	return ds.OneOf, true
+}
+
+// writeDirectives writes the comment directives of a field, the title and description are read from the
+// data schema of the field and the other annotations from the data schema of its value
+func writeDirectives(b *strings.Builder, field, value wot.DataSchema, indent string) {
+	title, description := field.Title, field.Description
+	if title == "" {
+		title = value.Title
+	}
+	if description == "" {
+		description = value.Description
+	}
+	var directives []string
+	if title != "" {
+		directives = append(directives, "title "+directiveValue(title))
+	}
+	if description != "" {
+		directives = append(directives, "description "+directiveValue(description))
+	}
+	if value.Unit != "" {
+		directives = append(directives, "unit "+directiveValue(value.Unit))
+	}
+	if value.Format != "" {
+		directives = append(directives, "format "+directiveValue(value.Format))
+	}
+	if value.ReadOnly {
+		directives = append(directives, "readOnly")
+	}
+	if value.WriteOnly {
+		directives = append(directives, "writeOnly")
+	}
+	if value.NumberSchema != nil && value.Minimum != nil {
+		directives = append(directives, "minimum "+strconv.FormatFloat(*value.Minimum, 'g', -1, 64))
+	}
+	if value.NumberSchema != nil && value.Maximum != nil {
+		directives = append(directives, "maximum "+strconv.FormatFloat(*value.Maximum, 'g', -1, 64))
+	}
+	for _, d := range directives {
+		fmt.Fprintf(b, "%s// %s%s\n", indent, directivePrefix, d)
+	}
+}
+
+// affordanceDirectiveComment returns the directive classifying the RPC with the title and description of the affordance
+func affordanceDirectiveComment(kind string, ia wot.InteractionAffordance, args ...string) string {
+	if ia.Title != "" {
+		args = append(args, `title="`+directiveValue(ia.Title)+`"`)
+	}
+	if ia.Description != "" {
+		args = append(args, `description="`+directiveValue(ia.Description)+`"`)
+	}
+	return "// " + directivePrefix + strings.TrimSpace(kind+" "+strings.Join(args, " "))
+}
+
+// propertyOps determines if the property is readable and writable from the operations of its forms. Without
+// operations, the default operations of the TD apply: readproperty unless it is writeOnly, writeproperty unless
+// it is readOnly
+func propertyOps(p wot.PropertyAffordance) (bool, bool) {
+	readable, writable, explicit := false, false, false
+	for _, f := range p.Forms {
+		for _, op := range f.Ops() {
+			explicit = true
+			readable = readable || op == "readproperty"
+			writable = writable || op == "writeproperty"
+		}
+	}
+	if !explicit {
+		return !p.DataSchema.WriteOnly, !p.DataSchema.ReadOnly
+	}
+	return readable, writable
+}
+
+// nameArg returns the name argument of a directive, names with whitespaces are quoted
+func nameArg(name string) string {
+	name = directiveValue(name)
+	if strings.ContainsAny(name, " \t") {
+		return `name="` + name + `"`
+	}
+	return "name=" + name
+}
+
+// affordanceNameArgs returns the name argument of the directive of an action or event, if the RPC cannot be named
+// like the affordance
+func affordanceNameArgs(name string) []string {
+	if protoIdentifier(name) == name {
+		return nil
+	}
+	return []string{nameArg(name)}
+}
+
+// reverse derives the proto file and the classification config of the TD. The fields follow the order of the members
+// recorded by memberOrders and keep the numbers of the previously derived proto file, cf. writeMessage
+func reverse(td wot.ThingDescription, orders map[string][]string, previous map[string]*messageNumbers) (string, affClassConfigs, error) {
+	if td.Title == "" {
+		return "", affClassConfigs{}, errors.New("the Thing Description has no title to name the service")
+	}
+	r := &reverser{names: map[string]bool{emptyMessage: true}, orders: orders, previous: previous}
+	ac := affClassConfigs{configs: map[string]affClassConfig{}}
+	rpcNames := map[string]bool{}
+	var rpcs []string
+	addRPC := func(comment, name, req, res string, streaming bool) string {
+		name = uniqueName(rpcNames, name)
+		if streaming {
+			res = "stream " + res
+		}
+		if comment != "" {
+			rpcs = append(rpcs, "  "+comment)
+		}
+		rpcs = append(rpcs, fmt.Sprintf("  rpc %s(%s) returns (%s);", name, req, res))
+		ac.names = append(ac.names, name)
+		return name
+	}
+
+	for _, k := range td.PropertyNames() {
+		p := td.Properties[k]
+		message := r.message(k, &p.DataSchema, "/properties/"+pointerEscaper.Replace(k)+"/properties")
+		args := []string{nameArg(k)}
+		if p.Observable {
+			args = append(args, "observable")
+		}
+		comment := affordanceDirectiveComment("property", p.InteractionAffordance, args...)
+		readable, writable := propertyOps(p)
+		if readable {
+			req := r.emptyMessage()
+			config := affClassConfig{AffClass: "property", Name: k, Op: "readproperty"}
+			if len(p.UriVariables) != 0 {
+				req = r.message("Get"+camelCase(k)+"Request", &wot.DataSchema{
+					DataType:     "object",
+					ObjectSchema: &wot.ObjectSchema{Properties: p.UriVariables},
+				}, "/properties/"+pointerEscaper.Replace(k)+"/uriVariables")
+				for v := range p.UriVariables {
+					config.UriVariables = append(config.UriVariables, protoIdentifier(v))
+				}
+				sort.Strings(config.UriVariables)
+			}
+			ac.configs[addRPC(comment, "Get"+camelCase(k), req, message, false)] = config
+			comment = affordanceDirectiveComment("property", wot.InteractionAffordance{}, nameArg(k))
+		}
+		if writable {
+			ac.configs[addRPC(comment, "Set"+camelCase(k), message, r.emptyMessage(), false)] =
+				affClassConfig{AffClass: "property", Name: k, Op: "writeproperty"}
+		}
+	}
+	for _, k := range td.ActionNames() {
+		a := td.Actions[k]
+		pointer := "/actions/" + pointerEscaper.Replace(k)
+		req := r.message(k+"Request", a.Input, pointer+"/input/properties")
+		res := r.message(k+"Response", a.Output, pointer+"/output/properties")
+		comment := affordanceDirectiveComment("action", a.InteractionAffordance, affordanceNameArgs(k)...)
+		ac.configs[addRPC(comment, protoIdentifier(k), req, res, false)] = affClassConfig{AffClass: "action", Name: k}
+	}
+	for _, k := range td.EventNames() {
+		e := td.Events[k]
+		pointer := "/events/" + pointerEscaper.Replace(k)
+		req := r.message(k+"Request", e.Subscription, pointer+"/subscription/properties")
+		res := r.message(k+"Event", e.Data, pointer+"/data/properties")
+		comment := affordanceDirectiveComment("event", e.InteractionAffordance, affordanceNameArgs(k)...)
+		ac.configs[addRPC(comment, protoIdentifier(k), req, res, true)] = affClassConfig{AffClass: "event", Name: k}
+	}
+
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n\n")
+	pkg := strings.ToLower(protoIdentifier(td.Title))
+	if td.Version != nil && packageVersionPattern.MatchString(td.Version.Instance) {
+		pkg += "." + td.Version.Instance
+	}
+	fmt.Fprintf(&b, "package %s;\n\n", pkg)
+	if td.Description != "" {
+		fmt.Fprintf(&b, "// %s\n", directiveValue(td.Description))
+	}
+	fmt.Fprintf(&b, "service %s {\n%s\n}\n", protoIdentifier(td.Title), strings.Join(rpcs, "\n"))
+	if r.empty {
+		fmt.Fprintf(&b, "\nmessage %s {}\n", emptyMessage)
+	}
+	for _, m := range r.messages {
+		b.WriteString("\n" + m)
+	}
+	return b.String(), ac, nil
+}
+
+// GenerateProtoBufFromTD reads the TD `tdFile` and writes the derived proto file and the classification config to
+// `outputDir`. The forward generation of the proto file with the classification config results in the same affordances.
+// If `outputDir` holds the proto file derived from a previous revision of the TD, its field numbers are kept and new
+// fields get unused numbers, so the messages stay wire-compatible
+func GenerateProtoBufFromTD(tdFile, outputDir string) error {
+	byteValue, err := readByteValueFromJsonFile(tdFile)
+	if err != nil {
+		return err
+	}
+	var td wot.ThingDescription
+	if err := json.Unmarshal(byteValue, &td); err != nil {
+		return err
+	}
+	orders, err := memberOrders(byteValue)
+	if err != nil {
+		return err
+	}
+	name := outputDir + "/" + strings.ToLower(protoIdentifier(td.Title)) + ".proto"
+	previous, err := previousNumbers(name)
+	if err != nil {
+		return err
+	}
+	protoFile, ac, err := reverse(td, orders, previous)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, []byte(protoFile), 0644); err != nil {
+		return err
+	}
+	return writeJsonFile(outputDir+"/classificationConfig.json", ac)
+}
@@ -0,0 +1,195 @@
+package grpcwot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// affordancesJSON serializes the affordances of the TD for comparison
+func affordancesJSON(t *testing.T, td wot.ThingDescription) string {
+	b, err := json.Marshal([]interface{}{td.Properties, td.Actions, td.Events})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestReverseRoundTrip(t *testing.T) {
+	td, _, err := GenerateBindings("cmd/prototd/test/enums/input.proto", "", "127.0.0.1", 50051)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	b, err := json.Marshal(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "td.jsonld"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateProtoBufFromTD(filepath.Join(dir, "td.jsonld"), dir); err != nil {
+		t.Fatal(err)
+	}
+	// the proto file must also be accepted by protoc-compatible parsers
+	if _, err := (&protoparse.Parser{ImportPaths: []string{dir}}).ParseFiles("washer.proto"); err != nil {
+		t.Fatal(err)
+	}
+	reversed, _, err := GenerateBindings(filepath.Join(dir, "washer.proto"), filepath.Join(dir, "classificationConfig.json"),
+		"127.0.0.1", 50051)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, result := affordancesJSON(t, td), affordancesJSON(t, reversed); expected != result {
+		t.Errorf("Expected the affordances\n%s\nafter the round trip, but got\n%s", expected, result)
+	}
+	if reversed.Version == nil || reversed.Version.Instance != "v1" {
+		t.Errorf("Expected the version v1 to be kept in the package, but got %v", reversed.Version)
+	}
+}
+
+func TestReverseDataSchemas(t *testing.T) {
+	td := wot.ThingDescription{
+		Title: "smart-plug",
+		Properties: map[string]wot.PropertyAffordance{
+			"power level": {DataSchema: wot.DataSchema{DataType: "number", ReadOnly: true}},
+		},
+		Actions: map[string]wot.ActionAffordance{
+			"switch": {Input: &wot.DataSchema{DataType: "object", ObjectSchema: &wot.ObjectSchema{
+				Properties: map[string]wot.DataSchema{
+					"state": {DataType: "string", Enum: []interface{}{"on", "off-delayed"}},
+					"mode":  {DataType: "string", Enum: []interface{}{"ECO", "mode"}},
+					"grid":  {DataType: "array", ArraySchema: &wot.ArraySchema{Items: &wot.DataSchema{DataType: "array", ArraySchema: &wot.ArraySchema{Items: &wot.DataSchema{DataType: "integer"}}}}},
+				},
+			}}},
+		},
+	}
+	protoFile, ac, err := reverse(td, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"service smart_plug {",
+		`  // @wot:property name="power level"`,
+		"  rpc GetPowerLevel(Empty) returns (PowerLevel);",
+		"  double value = 1;",
+		"  rpc switch(SwitchRequest) returns (Empty);",
+		// the values of the enum are no identifiers or conflict with the field mode
+		"  string mode = 2;",
+		"  string state = 3;",
+		"  repeated GridItem grid = 1;",
+		"    repeated int64 values = 1;",
+	} {
+		if !strings.Contains(protoFile, expected) {
+			t.Errorf("Expected the proto file to contain %q, but got\n%s", expected, protoFile)
+		}
+	}
+	if strings.Contains(protoFile, "SetPowerLevel") {
+		t.Errorf("Expected no setter for the read-only property, but got\n%s", protoFile)
+	}
+	if c := ac.configs["GetPowerLevel"]; c.AffClass != "property" || c.Name != "power level" {
+		t.Errorf("Expected the getter to be classified as property power level, but got %v", c)
+	}
+	if b, err := json.Marshal(ac); err != nil || !strings.HasPrefix(string(b), `{"GetPowerLevel":`) {
+		t.Errorf("Expected the classification config in the order of the RPCs, but got %s", b)
+	}
+	if _, _, err := reverse(wot.ThingDescription{}, nil, nil); err == nil {
+		t.Errorf("Expected an error for a TD without title")
+	}
+}
+
+func TestReverseAffordanceNames(t *testing.T) {
+	td := wot.ThingDescription{
+		Title: "plug",
+		Actions: map[string]wot.ActionAffordance{
+			"switch-on": {},
+		},
+		Events: map[string]wot.EventAffordance{
+			"power-changed": {Data: &wot.DataSchema{DataType: "number"}},
+		},
+	}
+	dir := t.TempDir()
+	b, err := json.Marshal(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "td.jsonld"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateProtoBufFromTD(filepath.Join(dir, "td.jsonld"), dir); err != nil {
+		t.Fatal(err)
+	}
+	// the names are kept with the classification config as well as with the directives of the proto file alone
+	for _, config := range []string{filepath.Join(dir, "classificationConfig.json"), ""} {
+		reversed, _, err := GenerateBindings(filepath.Join(dir, "plug.proto"), config, "127.0.0.1", 50051)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, ok := reversed.Actions["switch-on"]
+		if !ok || len(reversed.Actions) != 1 {
+			t.Errorf("Expected the action switch-on with config %q, but got %v", config, reversed.Actions)
+		} else if a.Forms[0].Href != "plug/switch-on" {
+			t.Errorf("Expected the target IRI plug/switch-on of the action, but got %s", a.Forms[0].Href)
+		}
+		if _, ok := reversed.Events["power-changed"]; !ok || len(reversed.Events) != 1 {
+			t.Errorf("Expected the event power-changed with config %q, but got %v", config, reversed.Events)
+		}
+	}
+}
+
+func TestReverseFieldNumbers(t *testing.T) {
+	dir := t.TempDir()
+	reverseTD := func(td string) string {
+		if err := os.WriteFile(filepath.Join(dir, "td.jsonld"), []byte(td), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := GenerateProtoBufFromTD(filepath.Join(dir, "td.jsonld"), dir); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "lamp.proto"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	// the fields follow the order of the TD
+	protoFile := reverseTD(`{"title": "lamp", "actions": {"fade": {"input": {"type": "object", "properties": {
+		"target": {"type": "number"}, "duration": {"type": "integer"}, "curve": {"type": "object", "properties": {
+			"steep": {"type": "number"}, "ease": {"type": "boolean"}}}}}}}}`)
+	for _, expected := range []string{"  double target = 1;", "  int64 duration = 2;", "  Curve curve = 3;",
+		"    double steep = 1;", "    bool ease = 2;"} {
+		if !strings.Contains(protoFile, expected) {
+			t.Errorf("Expected the proto file to contain %q, but got\n%s", expected, protoFile)
+		}
+	}
+	// the revision adds a member in front and removes one, the other fields keep their numbers
+	protoFile = reverseTD(`{"title": "lamp", "actions": {"fade": {"input": {"type": "object", "properties": {
+		"color": {"type": "string"}, "target": {"type": "number"}, "curve": {"type": "object", "properties": {
+			"linear": {"type": "boolean"}, "steep": {"type": "number"}, "ease": {"type": "boolean"}}}}}}}}`)
+	for _, expected := range []string{"  string color = 4;", "  double target = 1;", "  Curve curve = 3;",
+		"  reserved 2;", "    bool linear = 3;", "    double steep = 1;", "    bool ease = 2;"} {
+		if !strings.Contains(protoFile, expected) {
+			t.Errorf("Expected the proto file to contain %q, but got\n%s", expected, protoFile)
+		}
+	}
+	// the removed number stays reserved in the following revisions
+	protoFile = reverseTD(`{"title": "lamp", "actions": {"fade": {"input": {"type": "object", "properties": {
+		"target": {"type": "number"}, "duration": {"type": "integer"}}}}}}`)
+	for _, expected := range []string{"  double target = 1;", "  int64 duration = 5;", "  reserved 2, 3, 4;"} {
+		if !strings.Contains(protoFile, expected) {
+			t.Errorf("Expected the proto file to contain %q, but got\n%s", expected, protoFile)
+		}
+	}
+	if _, err := (&protoparse.Parser{ImportPaths: []string{dir}}).ParseFiles("lamp.proto"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := GenerateBindings(filepath.Join(dir, "lamp.proto"), filepath.Join(dir, "classificationConfig.json"),
+		"127.0.0.1", 50051); err != nil {
+		t.Fatal(err)
+	}
+}
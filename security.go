@@ -0,0 +1,271 @@
+package grpcwot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// securityConfig defines the security of the Thing, it is loaded from the security file or the (wot.thing) option
+// of the service. TLS switches the target IRIs of the forms to https
+type securityConfig struct {
+	TLS                 bool                          `json:"tls,omitempty"`
+	SecurityDefinitions map[string]wot.SecurityScheme `json:"securityDefinitions,omitempty"`
+	Security            []string                      `json:"security,omitempty"`
+}
+
+// literalEntries returns the values of a field in an aggregated literal, repeated fields can be given
+// as array or by repeating the field
+func literalEntries(l *proto.Literal, name string) []*proto.Literal {
+	var entries []*proto.Literal
+	for _, v := range l.OrderedMap {
+		if v.Name == name {
+			entries = append(entries, literalValues(v.Literal)...)
+		}
+	}
+	return entries
+}
+
+// literalStrings returns the string values of a repeated string field in an aggregated literal
+func literalStrings(l *proto.Literal, name string) []string {
+	var values []string
+	for _, v := range literalEntries(l, name) {
+		values = append(values, v.Source)
+	}
+	return values
+}
+
+// securitySchemeLiteral reads a SecurityScheme message of the (wot.thing) option
+func securitySchemeLiteral(l *proto.Literal) wot.SecurityScheme {
+	s := wot.SecurityScheme{}
+	for _, v := range l.OrderedMap {
+		switch v.Name {
+		case "scheme":
+			s.Scheme = v.Source
+		case "description":
+			s.Description = v.Source
+		case "in":
+			s.In = v.Source
+		case "name":
+			s.Name = v.Source
+		case "format":
+			s.Format = v.Source
+		case "alg":
+			s.Alg = v.Source
+		case "authorization":
+			s.Authorization = v.Source
+		case "token":
+			s.Token = v.Source
+		case "refresh":
+			s.Refresh = v.Source
+		case "flow":
+			s.Flow = v.Source
+		case "identity":
+			s.Identity = v.Source
+		}
+	}
+	if scopes := literalStrings(l, "scopes"); len(scopes) != 0 {
+		s.Scopes = scopes
+	}
+	return s
+}
+
+// thingOption reads the (wot.thing) option of a service
+func thingOption(s *proto.Service) (securityConfig, bool) {
+	var opts []*proto.Option
+	for _, v := range s.Elements {
+		if o, ok := v.(*proto.Option); ok {
+			opts = append(opts, o)
+		}
+	}
+	o, ok := findOption(opts, "wot.thing")
+	if !ok {
+		return securityConfig{}, false
+	}
+	c := securityConfig{
+		SecurityDefinitions: map[string]wot.SecurityScheme{},
+		Security:            literalStrings(&o.Constant, "security"),
+	}
+	for _, v := range o.Constant.OrderedMap {
+		if v.Name == "tls" {
+			c.TLS = literalBool(v.Literal)
+		}
+	}
+	// map entries are given as { key: "bearer_sc" value: { scheme: "bearer" } }
+	for _, e := range literalEntries(&o.Constant, "security_definitions") {
+		var key string
+		var value *proto.Literal
+		for _, v := range e.OrderedMap {
+			switch v.Name {
+			case "key":
+				key = v.Source
+			case "value":
+				value = v.Literal
+			}
+		}
+		if key != "" && value != nil {
+			c.SecurityDefinitions[key] = securitySchemeLiteral(value)
+		}
+	}
+	return c, true
+}
+
+// rpcSecurity returns the names of the security definitions required by the RPC, the classification config takes
+// precedence over the annotations in the proto file
+func (b *builder) rpcSecurity(r affs) []string {
+	if c, ok := b.ac[r.Name]; ok && len(c.Security) != 0 {
+		return c.Security
+	}
+	a, _ := affordanceAnnotation(r.RPC)
+	return a.security
+}
+
+// saveSecurity records the security of the RPC in the affordance classification
+func (b *builder) saveSecurity(r affs, security []string) {
+	if len(security) == 0 {
+		return
+	}
+	c := b.ac[r.Name]
+	c.Security = security
+	b.ac[r.Name] = c
+}
+
+// setFormSecurity sets the security of the forms, if the affordance requires a specific security
+func setFormSecurity(forms []wot.Form, security []string) {
+	if len(security) == 0 {
+		return
+	}
+	for k := range forms {
+		forms[k] = withSecurity(forms[k], security)
+	}
+}
+
+// propertyFormSecurity sets the security of the getter on readproperty forms and the security of the setter on
+// writeproperty forms, a form with both operations is split if getter and setter require different security
+func propertyFormSecurity(forms []wot.Form, getSecurity, setSecurity []string) []wot.Form {
+	var result []wot.Form
+	for _, f := range forms {
+		ops, _ := f.Op.([]string)
+		switch {
+		case !contains(ops, "writeproperty"):
+			result = append(result, withSecurity(f, getSecurity))
+		case !contains(ops, "readproperty"):
+			result = append(result, withSecurity(f, setSecurity))
+		case reflect.DeepEqual(getSecurity, setSecurity):
+			result = append(result, withSecurity(f, getSecurity))
+		default:
+			read, write := f, f
+			read.Op, write.Op = []string{"readproperty"}, []string{"writeproperty"}
+			result = append(result, withSecurity(read, getSecurity), withSecurity(write, setSecurity))
+		}
+	}
+	return result
+}
+
+// withSecurity returns the form with the security, if the affordance requires a specific security
+func withSecurity(f wot.Form, security []string) wot.Form {
+	if len(security) != 0 {
+		f.Security = security
+	}
+	return f
+}
+
+// unionStrings combines two lists of names without duplicates
+func unionStrings(a, b []string) []string {
+	u := append([]string{}, a...)
+	for _, v := range b {
+		if !contains(u, v) {
+			u = append(u, v)
+		}
+	}
+	return u
+}
+
+// mergeSecurityConfig combines the security of the (wot.thing) option with the security file,
+// where the file takes precedence
+func mergeSecurityConfig(option, file securityConfig) securityConfig {
+	c := securityConfig{
+		TLS:                 option.TLS || file.TLS,
+		SecurityDefinitions: map[string]wot.SecurityScheme{},
+		Security:            option.Security,
+	}
+	for _, s := range []securityConfig{option, file} {
+		for k, v := range s.SecurityDefinitions {
+			c.SecurityDefinitions[k] = v
+		}
+	}
+	if len(file.Security) != 0 {
+		c.Security = file.Security
+	}
+	return c
+}
+
+// formSecurity returns the names of the security definitions which are referenced in the forms of the TD
+func formSecurity(td wot.ThingDescription) []string {
+	var names []string
+	add := func(forms []wot.Form) {
+		for _, f := range forms {
+			if s, ok := f.Security.([]string); ok {
+				names = unionStrings(names, s)
+			}
+		}
+	}
+	for _, v := range td.Properties {
+		add(v.Forms)
+	}
+	for _, v := range td.Actions {
+		add(v.Forms)
+	}
+	for _, v := range td.Events {
+		add(v.Forms)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applySecurity adds the security definitions to the TD and sets the security of the Thing.
+// All security definitions referenced by the Thing or the forms must be defined
+func (b *builder) applySecurity(file securityConfig) error {
+	c := mergeSecurityConfig(b.security, file)
+	for k, v := range c.SecurityDefinitions {
+		b.td.SecurityDefinitions[k] = v
+	}
+	if len(c.Security) != 0 {
+		b.td.Security = c.Security
+	}
+	if c.TLS {
+		b.td.Base = strings.Replace(b.td.Base, "http://", "https://", 1)
+	}
+
+	referenced := formSecurity(b.td)
+	if s, ok := b.td.Security.([]string); ok {
+		referenced = unionStrings(s, referenced)
+	} else {
+		referenced = unionStrings([]string{noSecurityScheme}, referenced)
+	}
+	for _, v := range referenced {
+		if _, ok := b.td.SecurityDefinitions[v]; !ok {
+			return fmt.Errorf("the security definition %s is referenced but not defined", v)
+		}
+	}
+	if !contains(referenced, noSecurityScheme) {
+		delete(b.td.SecurityDefinitions, noSecurityScheme)
+	}
+	return nil
+}
+
+// readSecurityConfig reads the security file
+func readSecurityConfig(file string) (securityConfig, error) {
+	c := securityConfig{}
+	byteValue, err := readByteValueFromJsonFile(file)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(byteValue, &c)
+	return c, err
+}
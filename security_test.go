@@ -0,0 +1,89 @@
+package grpcwot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+func TestThingOption(t *testing.T) {
+	def, err := proto.NewParser(strings.NewReader(`syntax = "proto3";
+service Lock {
+  option (wot.thing) = {
+    tls: true
+    security_definitions: { key: "bearer_sc" value: { scheme: "bearer" in: "header" name: "authorization" } }
+    security_definitions: { key: "oauth2_sc" value: { scheme: "oauth2" flow: "client" scopes: ["read", "write"] } }
+    security: ["bearer_sc", "oauth2_sc"]
+  };
+}`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s *proto.Service
+	proto.Walk(def, proto.WithService(func(v *proto.Service) { s = v }))
+	c, ok := thingOption(s)
+	if !ok || !c.TLS {
+		t.Fatalf("Expected the (wot.thing) option with TLS, but got %v", c)
+	}
+	if !reflect.DeepEqual(c.Security, []string{"bearer_sc", "oauth2_sc"}) {
+		t.Errorf("Expected the security [bearer_sc oauth2_sc], but got %v", c.Security)
+	}
+	bearer := wot.SecurityScheme{Scheme: "bearer", In: "header", Name: "authorization"}
+	if !reflect.DeepEqual(c.SecurityDefinitions["bearer_sc"], bearer) {
+		t.Errorf("Expected the security definition %v, but got %v", bearer, c.SecurityDefinitions["bearer_sc"])
+	}
+	if scopes := c.SecurityDefinitions["oauth2_sc"].Scopes; !reflect.DeepEqual(scopes, []string{"read", "write"}) {
+		t.Errorf("Expected the scopes [read write], but got %v", scopes)
+	}
+}
+
+func TestApplySecurity(t *testing.T) {
+	b := newBuilder("127.0.0.1", 50051, nil)
+	b.security = securityConfig{
+		SecurityDefinitions: map[string]wot.SecurityScheme{"apikey_sc": {Scheme: "apikey", In: "header", Name: "x-api-key"}},
+		Security:            []string{"apikey_sc"},
+	}
+	file := securityConfig{
+		TLS:                 true,
+		SecurityDefinitions: map[string]wot.SecurityScheme{"basic_sc": {Scheme: "basic"}},
+		Security:            []string{"basic_sc"},
+	}
+	if err := b.applySecurity(file); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.td.Security, []string{"basic_sc"}) {
+		t.Errorf("Expected the security file to take precedence, but got %v", b.td.Security)
+	}
+	if _, ok := b.td.SecurityDefinitions[noSecurityScheme]; ok {
+		t.Errorf("Expected the unused %v to be removed, but got %v", noSecurityScheme, b.td.SecurityDefinitions)
+	}
+	if len(b.td.SecurityDefinitions) != 2 {
+		t.Errorf("Expected the security definitions of the option and the file, but got %v", b.td.SecurityDefinitions)
+	}
+	if b.td.Base != "https://127.0.0.1:50051/" {
+		t.Errorf("Expected https for TLS, but got %v", b.td.Base)
+	}
+
+	b = newBuilder("127.0.0.1", 50051, nil)
+	b.td.Actions["Reset"] = wot.ActionAffordance{InteractionAffordance: wot.InteractionAffordance{
+		Forms: []wot.Form{{Op: []string{"invokeaction"}, Href: "Lock/Reset", Security: []string{"psk_sc"}}},
+	}}
+	if err := b.applySecurity(securityConfig{}); err == nil {
+		t.Errorf("Expected an error for the undefined security definition psk_sc")
+	}
+}
+
+func TestPropertyFormSecurity(t *testing.T) {
+	forms := []wot.Form{{Op: []string{"readproperty", "writeproperty"}, Href: "Lock/Locked"}}
+	result := propertyFormSecurity(forms, nil, []string{"apikey_sc"})
+	if len(result) != 2 || result[0].Security != nil || !reflect.DeepEqual(result[1].Security, []string{"apikey_sc"}) {
+		t.Errorf("Expected separate forms for reading and writing, but got %v", result)
+	}
+	result = propertyFormSecurity(forms, []string{"apikey_sc"}, []string{"apikey_sc"})
+	if len(result) != 1 || !reflect.DeepEqual(result[0].Security, []string{"apikey_sc"}) {
+		t.Errorf("Expected one form with the security apikey_sc, but got %v", result)
+	}
+}
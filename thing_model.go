@@ -0,0 +1,185 @@
+package grpcwot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/jcs"
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+// Placeholders for the address of the gRPC service in a Thing Model, which are replaced on instantiation
+const (
+	hostPlaceholder = "{{GRPC_HOST}}"
+	portPlaceholder = "{{GRPC_PORT}}"
+)
+
+// rewriteHrefs applies f on all target IRIs of the forms in the TD
+func rewriteHrefs(td *wot.ThingDescription, f func(string) string) {
+	td.Base = f(td.Base)
+	rewriteForms := func(forms []wot.Form) {
+		for k := range forms {
+			forms[k].Href = f(forms[k].Href)
+		}
+	}
+	rewriteForms(td.Forms)
+	for _, v := range td.Properties {
+		rewriteForms(v.Forms)
+	}
+	for _, v := range td.Actions {
+		rewriteForms(v.Forms)
+	}
+	for _, v := range td.Events {
+		rewriteForms(v.Forms)
+	}
+}
+
+// copyThingDescription returns a deep copy of the TD, so the forms can be rewritten independently
+func copyThingDescription(td wot.ThingDescription) (wot.ThingDescription, error) {
+	var c wot.ThingDescription
+	b, err := json.Marshal(td)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// thingModel derives a Thing Model from the TD of the builder, the concrete address of the gRPC service
+// is replaced by placeholders, so devices built from the same proto file can share one model. The id of the
+// Thing identifies an instance and is left out, the version of the package is the version of the model
+// cf. https://www.w3.org/TR/wot-thing-description11/#thing-model
+func (b *builder) thingModel() (wot.ThingDescription, error) {
+	tm, err := copyThingDescription(b.td)
+	if err != nil {
+		return tm, err
+	}
+	tm.ID = ""
+	if tm.Version != nil {
+		tm.Version = &wot.VersionInfo{Model: tm.Version.Instance}
+	}
+	address := fmt.Sprintf("%s:%d", b.ip, b.port)
+	rewriteHrefs(&tm, func(href string) string {
+		return strings.Replace(href, address, hostPlaceholder+":"+portPlaceholder, 1)
+	})
+	tm.Context = wot.ContextV11
+	tm.Type = wot.TypeThingModel
+	return tm, nil
+}
+
+// isThingModelType determines if the @type holds the type of Thing Models
+func isThingModelType(t interface{}) bool {
+	switch v := t.(type) {
+	case string:
+		return v == wot.TypeThingModel
+	case []interface{}:
+		for _, e := range v {
+			if e == wot.TypeThingModel {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// instantiateThingModel creates the TD of a device hosting the gRPC service at ip and port from the Thing Model
+func instantiateThingModel(tm wot.ThingDescription, ip string, port int) (wot.ThingDescription, error) {
+	if !isThingModelType(tm.Type) {
+		return tm, fmt.Errorf("the document is not a Thing Model, the @type must include %s", wot.TypeThingModel)
+	}
+	td, err := copyThingDescription(tm)
+	if err != nil {
+		return td, err
+	}
+	replacer := strings.NewReplacer(hostPlaceholder, ip, portPlaceholder, strconv.Itoa(port))
+	rewriteHrefs(&td, replacer.Replace)
+	if td.Version != nil && td.Version.Instance == "" {
+		// the TD of the device keeps the version of its model, which is also the version of the instance
+		td.Version.Instance = td.Version.Model
+	}
+	switch v := td.Type.(type) {
+	case string:
+		td.Type = nil
+	case []interface{}:
+		types := []interface{}{}
+		for _, e := range v {
+			if e != wot.TypeThingModel {
+				types = append(types, e)
+			}
+		}
+		td.Type = types
+		if len(types) == 0 {
+			td.Type = nil
+		}
+	}
+	return td, nil
+}
+
+// InstantiateThingModel reads the Thing Model `tmFile` and writes the TD for the device at ip and port to `outputDir`
+func InstantiateThingModel(tmFile, outputDir, ip string, port int) error {
+	byteValue, err := readByteValueFromJsonFile(tmFile)
+	if err != nil {
+		return err
+	}
+	var tm wot.ThingDescription
+	err = json.Unmarshal(byteValue, &tm)
+	if err != nil {
+		return err
+	}
+	td, err := instantiateThingModel(tm, ip, port)
+	if err != nil {
+		return err
+	}
+	return writeJsonFile(outputDir+"/td.jsonld", td)
+}
+
+// jsonFormat is the format of the written JSON files
+type jsonFormat int
+
+const (
+	compactJSON jsonFormat = iota
+	prettyJSON
+	canonicalJSON
+)
+
+// writeJsonFile serializes v to the file
+func writeJsonFile(file string, v interface{}) error {
+	return writeJsonFileFormat(file, v, compactJSON)
+}
+
+// writeJsonFileFormat serializes v to the file in the given format. The hash of a canonical file is written to
+// <file>.sha256 in the format of sha256sum
+func writeJsonFileFormat(file string, v interface{}, format jsonFormat) error {
+	var bytes []byte
+	var err error
+	switch format {
+	case prettyJSON:
+		bytes, err = json.MarshalIndent(v, "", "  ")
+	case canonicalJSON:
+		bytes, err = jcs.Marshal(v)
+	default:
+		bytes, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(bytes)
+	if err != nil || format != canonicalJSON {
+		return err
+	}
+	hash, err := jcs.Hash(bytes)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file+".sha256", []byte(hash+"  "+filepath.Base(file)+"\n"), 0644)
+}
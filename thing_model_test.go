@@ -0,0 +1,114 @@
+package grpcwot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+)
+
+func TestThingModel(t *testing.T) {
+	b := newBuilder("192.168.1.10", 50051, newDataSchemaBuilder())
+	b.td.Title = "Test"
+	b.td.ID = b.thingID()
+	b.td.Version = &wot.VersionInfo{Instance: "v1"}
+	b.td.Actions["Reset"] = wot.ActionAffordance{
+		InteractionAffordance: wot.InteractionAffordance{Forms: b.getForms("Reset", []string{"invokeaction"})},
+	}
+	tm, err := b.thingModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.Type != wot.TypeThingModel || tm.Context != wot.ContextV11 {
+		t.Errorf("Expected a Thing Model, but got the @type %v and @context %v", tm.Type, tm.Context)
+	}
+	if tm.Base != "http://{{GRPC_HOST}}:{{GRPC_PORT}}/" {
+		t.Errorf("Expected placeholders for the address of the service, but got %v", tm.Base)
+	}
+	if b.td.Base != "http://192.168.1.10:50051/" {
+		t.Errorf("Expected the TD to be unchanged, but got %v", b.td.Base)
+	}
+	if tm.ID != "" {
+		t.Errorf("Expected no id of an instance in the Thing Model, but got %v", tm.ID)
+	}
+	if tm.Version == nil || tm.Version.Model != "v1" || tm.Version.Instance != "" {
+		t.Errorf("Expected the version v1 of the model, but got %v", tm.Version)
+	}
+
+	td, err := instantiateThingModel(tm, "10.0.0.7", 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if td.Type != nil {
+		t.Errorf("Expected no @type for the instance, but got %v", td.Type)
+	}
+	if td.Base != "http://10.0.0.7:9000/" {
+		t.Errorf("Expected the address of the device, but got %v", td.Base)
+	}
+	if td.Version == nil || td.Version.Instance != "v1" || td.Version.Model != "v1" {
+		t.Errorf("Expected the version v1 of the instance and its model, but got %v", td.Version)
+	}
+}
+
+var isThingModelTypeTest = []struct {
+	in  interface{}
+	out bool
+}{
+	{"tm:ThingModel", true},
+	{[]interface{}{"saref:LightSwitch", "tm:ThingModel"}, true},
+	{"saref:LightSwitch", false},
+	{nil, false},
+}
+
+func TestIsThingModelType(t *testing.T) {
+	for _, tt := range isThingModelTypeTest {
+		if result := isThingModelType(tt.in); result != tt.out {
+			t.Errorf("isThingModelType(%v) => %v, want %v", tt.in, result, tt.out)
+		}
+	}
+	if _, err := instantiateThingModel(wot.ThingDescription{Title: "Test"}, "10.0.0.7", 9000); err == nil {
+		t.Errorf("Expected an error for instantiating a TD")
+	}
+}
+
+func TestWriteJsonFileFormat(t *testing.T) {
+	v := map[string]interface{}{"title": "Lamp", "@context": "https://www.w3.org/2022/wot/td/v1.1", "version": 1.0}
+	tests := []struct {
+		format jsonFormat
+		out    string
+	}{
+		{compactJSON, `{"@context":"https://www.w3.org/2022/wot/td/v1.1","title":"Lamp","version":1}`},
+		{prettyJSON, "{\n  \"@context\": \"https://www.w3.org/2022/wot/td/v1.1\",\n  \"title\": \"Lamp\",\n  \"version\": 1\n}"},
+		{canonicalJSON, `{"@context":"https://www.w3.org/2022/wot/td/v1.1","title":"Lamp","version":1}`},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "td.jsonld")
+		if err := writeJsonFileFormat(file, v, tt.format); err != nil {
+			t.Fatal(err)
+		}
+		out, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.out {
+			t.Errorf("Expected %s, but got %s", tt.out, out)
+		}
+		hash, err := ioutil.ReadFile(file + ".sha256")
+		if tt.format != canonicalJSON {
+			if err == nil {
+				t.Errorf("Expected no hash for the format %d", tt.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(out)
+		if want := hex.EncodeToString(sum[:]) + "  td.jsonld\n"; string(hash) != want {
+			t.Errorf("Expected the hash %q, but got %q", want, hash)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package grpcwot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+// validationExtensions are the field options of protoc-gen-validate and buf validate which hold validation rules
+var validationExtensions = []string{"validate.rules", "buf.validate.field"}
+
+// validationRule is a single constraint of a field, e.g. (validate.rules).int32 = {gte: 0} results in the rule with
+// the path [int32 gte] and the value 0
+type validationRule struct {
+	path  []string
+	value *proto.Literal
+}
+
+// validationRules collects the rules of protoc-gen-validate and buf validate defined in the options of a field
+func validationRules(f *proto.Field) []validationRule {
+	var rules []validationRule
+	for _, o := range f.Options {
+		n := optionName(o)
+		for _, e := range validationExtensions {
+			if n != e && !strings.HasPrefix(n, e+".") {
+				continue
+			}
+			var path []string
+			if n != e {
+				path = strings.Split(strings.TrimPrefix(n, e+"."), ".")
+			}
+			rules = flattenValidationRules(rules, path, &o.Constant)
+		}
+	}
+	return rules
+}
+
+// flattenValidationRules resolves the aggregated constants of an option into single rules
+func flattenValidationRules(rules []validationRule, path []string, l *proto.Literal) []validationRule {
+	if len(l.OrderedMap) == 0 {
+		return append(rules, validationRule{path: path, value: l})
+	}
+	for _, v := range l.OrderedMap {
+		p := append(append([]string{}, path...), v.Name)
+		rules = flattenValidationRules(rules, p, v.Literal)
+	}
+	return rules
+}
+
+// isRequiredByRules determines if the rules require the field to be set
+func isRequiredByRules(rules []validationRule) bool {
+	for _, r := range rules {
+		p := strings.Join(r.path, ".")
+		if (p == "message.required" || p == "required") && literalBool(r.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// literalValues returns the single values of a literal, which can be an array literal
+func literalValues(l *proto.Literal) []*proto.Literal {
+	if len(l.Array) != 0 {
+		return l.Array
+	}
+	return []*proto.Literal{l}
+}
+
+// applyValidationRules translates the validation rules into constraints of the DataSchema
+func applyValidationRules(ds *wot.DataSchema, rules []validationRule) {
+	for _, r := range rules {
+		if len(r.path) < 2 {
+			continue
+		}
+		switch r.path[0] {
+		case "double", "float":
+			applyNumberRule(ds, r.path[1], r.value, false)
+		case "int32", "int64", "uint32", "uint64", "sint32", "sint64", "fixed32", "fixed64", "sfixed32", "sfixed64":
+			applyNumberRule(ds, r.path[1], r.value, true)
+		case "string":
+			applyStringRule(ds, r.path[1], r.value)
+		case "repeated":
+			applyRepeatedRule(ds, r)
+		}
+	}
+}
+
+// numberValue interprets a literal as numeric value of an integer or a number field
+func numberValue(l *proto.Literal, isInteger bool) (interface{}, bool) {
+	if isInteger {
+		if i, err := strconv.ParseInt(l.Source, 0, 64); err == nil {
+			return i, true
+		}
+	}
+	f, ok := literalFloat(l)
+	return f, ok
+}
+
+func applyNumberRule(ds *wot.DataSchema, rule string, l *proto.Literal, isInteger bool) {
+	switch rule {
+	case "const":
+		if v, ok := numberValue(l, isInteger); ok {
+			ds.Const = v
+		}
+		return
+	case "in":
+		for _, e := range literalValues(l) {
+			if v, ok := numberValue(e, isInteger); ok {
+				ds.Enum = append(ds.Enum, v)
+			}
+		}
+		return
+	}
+	f, ok := literalFloat(l)
+	if !ok {
+		return
+	}
+	if ds.NumberSchema == nil {
+		ds.NumberSchema = &wot.NumberSchema{}
+	}
+	switch rule {
+	case "gte":
+		ds.Minimum = &f
+	case "gt":
+		ds.ExclusiveMinimum = &f
+	case "lte":
+		ds.Maximum = &f
+	case "lt":
+		ds.ExclusiveMaximum = &f
+	}
+}
+
+// stringFormats maps the well-known string rules to the formats of JSON Schema
+var stringFormats = map[string]string{
+	"email":    "email",
+	"hostname": "hostname",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"uri":      "uri",
+	"uri_ref":  "uri-reference",
+	"uuid":     "uuid",
+}
+
+func applyStringRule(ds *wot.DataSchema, rule string, l *proto.Literal) {
+	if format, ok := stringFormats[rule]; ok {
+		if literalBool(l) {
+			ds.Format = format
+		}
+		return
+	}
+	switch rule {
+	case "const":
+		ds.Const = literalString(l)
+	case "in":
+		for _, e := range literalValues(l) {
+			ds.Enum = append(ds.Enum, literalString(e))
+		}
+	case "pattern":
+		if ds.StringSchema == nil {
+			ds.StringSchema = &wot.StringSchema{}
+		}
+		ds.Pattern = literalString(l)
+	case "len", "min_len", "max_len":
+		n, err := strconv.Atoi(l.Source)
+		if err != nil {
+			return
+		}
+		if ds.StringSchema == nil {
+			ds.StringSchema = &wot.StringSchema{}
+		}
+		if rule != "max_len" {
+			ds.MinLength = &n
+		}
+		if rule != "min_len" {
+			ds.MaxLength = &n
+		}
+	}
+}
+
+func applyRepeatedRule(ds *wot.DataSchema, r validationRule) {
+	if ds.ArraySchema == nil {
+		return
+	}
+	switch r.path[1] {
+	case "items":
+		if ds.Items != nil {
+			applyValidationRules(ds.Items, []validationRule{{path: r.path[2:], value: r.value}})
+		}
+	case "min_items", "max_items":
+		n, err := strconv.Atoi(r.value.Source)
+		if err != nil {
+			return
+		}
+		if r.path[1] == "min_items" {
+			ds.MinItems = &n
+		} else {
+			ds.MaxItems = &n
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package grpcwot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Interactions-HSG/grpcwot/pkg/wot"
+	"github.com/emicklei/proto"
+)
+
+func parseTestMessage(t *testing.T, src string) *proto.Message {
+	def, err := proto.NewParser(strings.NewReader(src)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m *proto.Message
+	proto.Walk(def, proto.WithMessage(func(v *proto.Message) { m = v }))
+	return m
+}
+
+func TestValidationRules(t *testing.T) {
+	m := parseTestMessage(t, `syntax = "proto3";
+message Test {
+  int32 level = 1 [(validate.rules).int32 = {gte: 0, lte: 100}];
+  string name = 2 [(buf.validate.field).string.pattern = "^[a-z]+$", (buf.validate.field).required = true];
+}`)
+	level := validationRules(m.Elements[0].(*proto.NormalField).Field)
+	if len(level) != 2 || strings.Join(level[0].path, ".") != "int32.gte" || level[1].value.Source != "100" {
+		t.Errorf("Expected the rules int32.gte and int32.lte, but got %v", level)
+	}
+	name := validationRules(m.Elements[1].(*proto.NormalField).Field)
+	if len(name) != 2 || strings.Join(name[0].path, ".") != "string.pattern" {
+		t.Errorf("Expected the rules string.pattern and required, but got %v", name)
+	}
+	if isRequiredByRules(level) || !isRequiredByRules(name) {
+		t.Errorf("Expected only the field name to be required")
+	}
+}
+
+func TestApplyValidationRules(t *testing.T) {
+	m := parseTestMessage(t, `syntax = "proto3";
+message Test {
+  double balance = 1 [(validate.rules).double = {gt: -1, lt: 1}];
+  uint32 mode = 2 [(validate.rules).uint32 = {in: [1, 2, 3]}];
+  string name = 3 [(validate.rules).string = {min_len: 1, max_len: 32, email: true}];
+  string code = 4 [(validate.rules).string = {pattern: "^\\d+\x2D[a-z]$", in: ["a\"b", "c\'d"]}];
+}`)
+	balance := wot.DataSchema{DataType: "number"}
+	applyValidationRules(&balance, validationRules(m.Elements[0].(*proto.NormalField).Field))
+	if balance.NumberSchema == nil || *balance.ExclusiveMinimum != -1.0 || *balance.ExclusiveMaximum != 1.0 {
+		t.Errorf("Expected the exclusive bounds -1 and 1, but got %v", balance.NumberSchema)
+	}
+	mode := wot.DataSchema{DataType: "integer"}
+	applyValidationRules(&mode, validationRules(m.Elements[1].(*proto.NormalField).Field))
+	if len(mode.Enum) != 3 || mode.Enum[0] != int64(1) {
+		t.Errorf("Expected the enum [1 2 3], but got %v", mode.Enum)
+	}
+	name := wot.DataSchema{DataType: "string"}
+	applyValidationRules(&name, validationRules(m.Elements[2].(*proto.NormalField).Field))
+	if name.StringSchema == nil || *name.MinLength != 1 || *name.MaxLength != 32 || name.Format != "email" {
+		t.Errorf("Expected the length 1 to 32 and the format email, but got %v, %v", name.StringSchema, name.Format)
+	}
+	code := wot.DataSchema{DataType: "string"}
+	applyValidationRules(&code, validationRules(m.Elements[3].(*proto.NormalField).Field))
+	if code.StringSchema == nil || code.Pattern != `^\d+-[a-z]$` {
+		t.Errorf("Expected the unescaped pattern ^\\d+-[a-z]$, but got %v", code.StringSchema)
+	}
+	if len(code.Enum) != 2 || code.Enum[0] != `a"b` || code.Enum[1] != "c'd" {
+		t.Errorf("Expected the unescaped enum [a\"b c'd], but got %v", code.Enum)
+	}
+}
+
+func TestRepeatedValidationRules(t *testing.T) {
+	m := parseTestMessage(t, `syntax = "proto3";
+message Test {
+  repeated string tags = 1 [(validate.rules).repeated = {min_items: 1, items: {string: {min_len: 2}}}];
+}`)
+	dsb := newDataSchemaBuilder()
+	ds := dsb.normalFieldToDataSchema(m.Elements[0].(*proto.NormalField), "Test")
+	if ds.DataType != "array" || ds.ArraySchema == nil || ds.Items == nil {
+		t.Fatalf("Expected an array of strings, but got %v", ds)
+	}
+	if ds.MinItems == nil || *ds.MinItems != 1 || ds.MaxItems != nil {
+		t.Errorf("Expected at least one item, but got %v", ds.ArraySchema)
+	}
+	if ds.Items.DataType != "string" || ds.Items.StringSchema == nil || *ds.Items.MinLength != 2 {
+		t.Errorf("Expected items with a minimal length of 2, but got %v", ds.Items)
+	}
+}